@@ -0,0 +1,219 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+)
+
+// A Backend generates files from the Model of a WSDL, e.g. the Go client, or
+// stubs and documentation in other languages.
+type Backend interface {
+	Generate(model *Model) ([]*File, error)
+}
+
+// File is a file generated by a Backend.
+type File struct {
+	// Name is the slash separated path of the file, relative to the output
+	// directory.
+	Name    string
+	Content []byte
+}
+
+// GoClient is the backend generating the Go client: the types and the
+// operations of the services.
+type GoClient struct {
+	// File is the name of the generated file, the package name with the .go
+	// extension by default. With SplitFiles, it is the main file.
+	File string
+	// Templates override the blocks of the templates.
+	Templates Templates
+	// Layout is how the client is laid out in files, SingleFile by default.
+	Layout Layout
+}
+
+// Generate implements Backend. The source is returned unformatted along with
+// the error when it doesn't parse, so it can be inspected.
+func (b GoClient) Generate(model *Model) ([]*File, error) {
+	layout, err := ParseLayout(string(b.Layout))
+	if err != nil {
+		return nil, err
+	}
+	name := b.File
+	if name == "" {
+		name = model.Package + ".go"
+	}
+	if layout == SplitFiles {
+		return renderSplit(model, b.Templates, name)
+	}
+
+	code, err := renderShared(model, b.Templates)
+	if err != nil {
+		return nil, err
+	}
+	source, err := code.Client()
+	return []*File{{Name: name, Content: source}}, err
+}
+
+// GoServer is the backend generating the Go server.
+type GoServer struct {
+	// File is the name of the generated file, the name of the client file
+	// prefixed with "server" by default.
+	File string
+	// Templates override the blocks of the templates.
+	Templates Templates
+}
+
+// Generate implements Backend. The source is returned unformatted along with
+// the error when it doesn't parse, so it can be inspected.
+func (b GoServer) Generate(model *Model) ([]*File, error) {
+	code, err := renderShared(model, b.Templates)
+	if err != nil {
+		return nil, err
+	}
+	name := b.File
+	if name == "" {
+		name = "server" + model.Package + ".go"
+	}
+	source, err := code.ServerSource()
+	return []*File{{Name: name, Content: source}}, err
+}
+
+// rendering is the Code rendered from a model with templates.
+type rendering struct {
+	templates Templates
+	code      *Code
+	err       error
+}
+
+// renderShared returns the Code of model rendered with templates. It is
+// rendered once for the backends sharing the model and the templates, such as
+// GoClient and GoServer, which only use a part of it each.
+func renderShared(model *Model, templates Templates) (*Code, error) {
+	if r := model.rendered; r != nil && r.templates.equal(templates) {
+		return r.code, r.err
+	}
+	code, err := renderGo(model, templates)
+	model.rendered = &rendering{templates: templates, code: code, err: err}
+	return code, err
+}
+
+// ExecBackend runs an external command as a backend, so generators can be
+// written in any language. The command reads a JSON request from its standard
+// input:
+//
+//	{"version": 1, "model": {...}}
+//
+// where model is the Model encoded as JSON, and writes a JSON response to its
+// standard output:
+//
+//	{"files": [{"name": "service.ts", "content": "..."}], "error": ""}
+//
+// A non-empty error, or a non-zero exit status, fails the generation. What the
+// command writes to its standard error is passed through to Stderr.
+type ExecBackend struct {
+	Command string
+	Args    []string
+	// Stderr receives the standard error of the command, os.Stderr by
+	// default.
+	Stderr io.Writer
+}
+
+// ExecProtocolVersion is the version of the protocol spoken with ExecBackend
+// commands.
+const ExecProtocolVersion = 1
+
+// ExecRequest is the request written to ExecBackend commands.
+type ExecRequest struct {
+	Version int    `json:"version"`
+	Model   *Model `json:"model"`
+}
+
+// ExecResponse is the response read from ExecBackend commands.
+type ExecResponse struct {
+	Files []ExecFile `json:"files"`
+	Error string     `json:"error,omitempty"`
+}
+
+// ExecFile is a file generated by an ExecBackend command.
+type ExecFile struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// Generate implements Backend.
+func (b ExecBackend) Generate(model *Model) ([]*File, error) {
+	request, err := json.Marshal(ExecRequest{Version: ExecProtocolVersion, Model: model})
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(b.Command, b.Args...)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = b.Stderr
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("running backend %s: %w", b.Command, err)
+	}
+
+	var response ExecResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return nil, fmt.Errorf("reading the response of backend %s: %w", b.Command, err)
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("backend %s: %s", b.Command, response.Error)
+	}
+
+	files := make([]*File, 0, len(response.Files))
+	for _, f := range response.Files {
+		files = append(files, &File{Name: f.Name, Content: []byte(f.Content)})
+	}
+	return files, nil
+}
+
+// Generate builds the Model of the WSDL and passes it to backends, returning
+// the files they generate in order.
+//
+// Problems found along the way, including the errors of the backends, are
+// reported by Diagnostics. When any of them is an error, or a warning in
+// strict mode, Generate returns a *DiagnosticError along with the files
+// generated, which may be incomplete.
+func (g *GoWSDL) Generate(backends ...Backend) ([]*File, error) {
+	if err := g.load(); err != nil {
+		g.fail(CodeLoad, Position{}, err)
+		return nil, g.failure()
+	}
+	return g.generate(g.buildModel(), backends), g.failure()
+}
+
+// generate passes model to backends, returning the files they generate and
+// reporting their errors.
+func (g *GoWSDL) generate(model *Model, backends []Backend) []*File {
+	var files []*File
+	for _, b := range backends {
+		generated, err := b.Generate(model)
+		if err != nil {
+			g.failWith(CodeBackend, err)
+		}
+		for _, f := range generated {
+			if !fs.ValidPath(f.Name) || f.Name == "." {
+				g.fail(CodeBackend, Position{}, fmt.Errorf("invalid file name %q, it should be a relative path within the output directory", f.Name))
+				continue
+			}
+			files = append(files, f)
+		}
+	}
+	return files
+}
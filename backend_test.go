@@ -0,0 +1,205 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// backendFunc adapts a function to the Backend interface.
+type backendFunc func(model *Model) ([]*File, error)
+
+func (f backendFunc) Generate(model *Model) ([]*File, error) {
+	return f(model)
+}
+
+func TestGenerate(t *testing.T) {
+	g, err := New("fixtures/stock.wsdl", WithPackage("stock"), WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var services []string
+	custom := backendFunc(func(model *Model) ([]*File, error) {
+		for _, s := range model.Services {
+			services = append(services, s.Name)
+		}
+		return []*File{{Name: "docs/services.txt", Content: []byte(strings.Join(services, "\n"))}}, nil
+	})
+
+	files, err := g.Generate(GoClient{}, GoServer{}, custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	if got, want := strings.Join(names, " "), "stock.go serverstock.go docs/services.txt"; got != want {
+		t.Errorf("got files %s, want %s", got, want)
+	}
+	if !bytes.Contains(files[0].Content, []byte("func NewStockQuotePortType(client *soap.Client) StockQuotePortType {")) {
+		t.Errorf("got client\n%s", files[0].Content)
+	}
+	if !bytes.Contains(files[1].Content, []byte("func Endpoint(w http.ResponseWriter, r *http.Request) {")) {
+		t.Errorf("got server\n%s", files[1].Content)
+	}
+	if string(files[2].Content) != "StockQuotePortType" {
+		t.Errorf("got %q from the custom backend", files[2].Content)
+	}
+}
+
+func TestGoBackendsRenderOnce(t *testing.T) {
+	g, err := New("fixtures/stock.wsdl", WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := g.Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (GoClient{}).Generate(m); err != nil {
+		t.Fatal(err)
+	}
+	rendered := m.rendered
+	if _, err := (GoServer{}).Generate(m); err != nil {
+		t.Fatal(err)
+	}
+	if rendered == nil || m.rendered != rendered {
+		t.Error("the server should be generated from the code rendered for the client")
+	}
+
+	// Other templates render the model again.
+	if _, err := (GoServer{Templates: Templates{"Methods": "{{/* none */}}"}}).Generate(m); err != nil {
+		t.Fatal(err)
+	}
+	if m.rendered == rendered {
+		t.Error("the code rendered with other templates should not be shared")
+	}
+}
+
+func TestGenerateBackendError(t *testing.T) {
+	g, err := New("fixtures/stock.wsdl", WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errBroken := errors.New("broken")
+	broken := backendFunc(func(*Model) ([]*File, error) { return nil, errBroken })
+	escaping := backendFunc(func(*Model) ([]*File, error) {
+		return []*File{{Name: "../outside.txt"}}, nil
+	})
+
+	files, err := g.Generate(GoClient{File: "stock.go"}, broken, escaping)
+	if len(files) != 1 || files[0].Name != "stock.go" {
+		t.Errorf("got %d files, want the client only", len(files))
+	}
+	var de *DiagnosticError
+	if !errors.As(err, &de) || len(de.Diagnostics) != 2 {
+		t.Fatalf("got %v, want 2 diagnostics", err)
+	}
+	if !errors.Is(err, errBroken) {
+		t.Errorf("got %v, want it to wrap the backend error", err)
+	}
+	for _, d := range de.Diagnostics {
+		if d.Code != CodeBackend {
+			t.Errorf("got %s, want a %s diagnostic", d, CodeBackend)
+		}
+	}
+}
+
+func TestGoClientSplitFiles(t *testing.T) {
+	g, err := New("fixtures/namespaces.wsdl", WithPackage("orders"), WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := g.Generate(GoClient{Layout: SplitFiles})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	want := "orders.go example_com_shipping_v2_types.go example_com_orders_types.go orders_soap_service.go"
+	if got := strings.Join(names, " "); got != want {
+		t.Fatalf("got files %s, want %s", got, want)
+	}
+
+	for _, f := range files {
+		if !bytes.HasPrefix(f.Content, []byte("// Code generated by gowsdl DO NOT EDIT.\n\npackage orders\n")) {
+			t.Errorf("%s should start with the package clause, got\n%s", f.Name, f.Content)
+		}
+	}
+	if !bytes.Contains(files[0].Content, []byte("type AnyType struct")) || bytes.Contains(files[0].Content, []byte("func New")) {
+		t.Errorf("main file should only declare the helper types, got\n%s", files[0].Content)
+	}
+	// Types don't use the context of the operations, which must not be
+	// imported.
+	if bytes.Contains(files[1].Content, []byte(`"context"`)) {
+		t.Errorf("%s imports packages it doesn't use\n%s", files[1].Name, files[1].Content)
+	}
+	if !bytes.Contains(files[3].Content, []byte(`"context"`)) || !bytes.Contains(files[3].Content, []byte("func NewOrdersSoap(client *soap.Client) OrdersSoap {")) {
+		t.Errorf("got service file\n%s", files[3].Content)
+	}
+
+	if _, err := g.Generate(GoClient{Layout: "tree"}); err == nil {
+		t.Error("unknown layouts should fail")
+	}
+}
+
+// TestExecBackendCommand is run by TestExecBackend as the command of an
+// ExecBackend.
+func TestExecBackendCommand(t *testing.T) {
+	if os.Getenv("GOWSDL_TEST_BACKEND") != "1" {
+		t.Skip("run by TestExecBackend")
+	}
+
+	var request ExecRequest
+	response := ExecResponse{}
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+		response.Error = err.Error()
+	} else {
+		var ops []string
+		for _, s := range request.Model.Services {
+			for _, op := range s.Operations {
+				ops = append(ops, s.Name+"."+op.Name+"("+op.Input.Type+") "+op.Output.Type)
+			}
+		}
+		response.Files = []ExecFile{{Name: request.Model.Package + ".txt", Content: strings.Join(ops, "\n")}}
+	}
+	json.NewEncoder(os.Stdout).Encode(response)
+	os.Exit(0)
+}
+
+func TestExecBackend(t *testing.T) {
+	os.Setenv("GOWSDL_TEST_BACKEND", "1")
+	defer os.Unsetenv("GOWSDL_TEST_BACKEND")
+
+	g, err := New("fixtures/stock.wsdl", WithPackage("stock"), WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := g.Generate(ExecBackend{Command: os.Args[0], Args: []string{"-test.run=^TestExecBackendCommand$"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name != "stock.txt" {
+		t.Fatalf("got %d files, want stock.txt", len(files))
+	}
+	if got, want := string(files[0].Content), "StockQuotePortType.GetLastTradePrice(TradePriceRequest) TradePrice"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// downloadCache stores downloaded documents by the SHA-256 of their content,
+// under objects/, and the hash of the document last downloaded from a URL
+// under urls/, keyed by the SHA-256 of the URL. A nil cache stores nothing.
+type downloadCache struct {
+	dir string
+}
+
+func newDownloadCache(dir string) *downloadCache {
+	if dir == "" {
+		return nil
+	}
+	return &downloadCache{dir: dir}
+}
+
+func (c *downloadCache) urlPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, "urls", hex.EncodeToString(sum[:]))
+}
+
+func (c *downloadCache) objectPath(hash string) string {
+	return filepath.Join(c.dir, "objects", hash)
+}
+
+// get returns the document cached for url. Documents whose content doesn't
+// match their hash anymore are ignored.
+func (c *downloadCache) get(url string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	hash, err := ioutil.ReadFile(c.urlPath(url))
+	if err != nil {
+		return nil, false
+	}
+	hash = bytes.TrimSpace(hash)
+
+	data, err := ioutil.ReadFile(c.objectPath(string(hash)))
+	if err != nil {
+		return nil, false
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != string(hash) {
+		return nil, false
+	}
+	return data, true
+}
+
+// put stores data as the document downloaded from url.
+func (c *downloadCache) put(url string, data []byte) error {
+	if c == nil {
+		return nil
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if err := writeFileAtomic(c.objectPath(hash), data); err != nil {
+		return err
+	}
+	return writeFileAtomic(c.urlPath(url), []byte(hash+"\n"))
+}
+
+// writeFileAtomic writes data to a temporary file renamed to file, so
+// concurrent runs never read a partially written file.
+func writeFileAtomic(file string, data []byte) error {
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,50 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadCache(t *testing.T) {
+	c := newDownloadCache(t.TempDir())
+
+	if _, ok := c.get("http://example.org/my.wsdl"); ok {
+		t.Fatal("empty cache should miss")
+	}
+	if err := c.put("http://example.org/my.wsdl", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.put("http://example.org/my.wsdl", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := c.get("http://example.org/my.wsdl"); !ok || string(data) != "v2" {
+		t.Errorf("got %q, %v wanted the last document put", data, ok)
+	}
+
+	// Documents not matching their hash are ignored.
+	objects, err := filepath.Glob(filepath.Join(c.dir, "objects", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range objects {
+		if err := ioutil.WriteFile(object, []byte("corrupted"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := c.get("http://example.org/my.wsdl"); ok {
+		t.Error("corrupted documents should miss")
+	}
+
+	var disabled *downloadCache
+	if err := disabled.put("http://example.org/my.wsdl", []byte("v1")); err != nil {
+		t.Error(err)
+	}
+	if _, ok := disabled.get("http://example.org/my.wsdl"); ok {
+		t.Error("disabled cache should miss")
+	}
+}
@@ -0,0 +1,223 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// A Catalog maps the locations of WSDL and XSD documents, and the namespaces
+// imported without location, to other locations, usually vendored local
+// files.
+//
+// Catalogs are read either from an OASIS XML Catalog
+// (https://www.oasis-open.org/committees/entity/spec.html) or from a JSON
+// object mapping locations and namespaces to paths:
+//
+//	{
+//	  "http://schemas.example.com/common.xsd": "vendor/common.xsd",
+//	  "urn:example:types": "vendor/types.xsd",
+//	  "http://schemas.example.com/v2/": "vendor/v2/"
+//	}
+//
+// Keys ending with a slash map every location starting with them. Relative
+// paths are resolved against the catalog file.
+type Catalog struct {
+	entries  map[string]*Location
+	rewrites []catalogRewrite
+	next     []*Catalog
+}
+
+// catalogRewrite replaces the prefix of a location with the one of a local
+// directory.
+type catalogRewrite struct {
+	prefix  string
+	rewrite string
+	base    *Location
+}
+
+// oasisCatalog holds the entries of an OASIS XML Catalog this package
+// understands. Entries nested in groups are flattened.
+type oasisCatalog struct {
+	Systems []struct {
+		ID  string `xml:"systemId,attr"`
+		URI string `xml:"uri,attr"`
+	} `xml:"system"`
+	URIs []struct {
+		Name string `xml:"name,attr"`
+		URI  string `xml:"uri,attr"`
+	} `xml:"uri"`
+	Publics []struct {
+		ID  string `xml:"publicId,attr"`
+		URI string `xml:"uri,attr"`
+	} `xml:"public"`
+	RewriteSystems []struct {
+		Prefix  string `xml:"systemIdStartString,attr"`
+		Rewrite string `xml:"rewritePrefix,attr"`
+	} `xml:"rewriteSystem"`
+	RewriteURIs []struct {
+		Prefix  string `xml:"uriStartString,attr"`
+		Rewrite string `xml:"rewritePrefix,attr"`
+	} `xml:"rewriteURI"`
+	NextCatalogs []struct {
+		Catalog string `xml:"catalog,attr"`
+	} `xml:"nextCatalog"`
+	Groups []oasisCatalog `xml:"group"`
+}
+
+// LoadCatalog reads the OASIS XML Catalog or JSON mapping file at file.
+func LoadCatalog(file string) (*Catalog, error) {
+	loc, err := ParseLocation(file)
+	if err != nil {
+		return nil, err
+	}
+	return loadCatalog(loc, make(map[string]bool))
+}
+
+func loadCatalog(loc *Location, loaded map[string]bool) (*Catalog, error) {
+	if !loc.isFile() {
+		return nil, fmt.Errorf("catalog %s must be a local file", loc)
+	}
+	loaded[loc.canonical()] = true
+
+	data, err := ioutil.ReadFile(loc.f)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{entries: make(map[string]*Location)}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		err = c.readMapping(loc, data)
+	} else {
+		err = c.readOASIS(loc, data, loaded)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read catalog %s: %w", loc, err)
+	}
+	return c, nil
+}
+
+func (c *Catalog) readMapping(base *Location, data []byte) error {
+	var mapping map[string]string
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return err
+	}
+
+	for key, path := range mapping {
+		if strings.HasSuffix(key, "/") {
+			c.rewrites = append(c.rewrites, catalogRewrite{prefix: key, rewrite: path, base: base})
+			continue
+		}
+		if err := c.add(base, key, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) readOASIS(base *Location, data []byte, loaded map[string]bool) error {
+	catalog := new(oasisCatalog)
+	if err := xml.Unmarshal(data, catalog); err != nil {
+		return err
+	}
+	return c.addOASIS(base, catalog, loaded)
+}
+
+func (c *Catalog) addOASIS(base *Location, catalog *oasisCatalog, loaded map[string]bool) error {
+	for _, e := range catalog.Systems {
+		if err := c.add(base, e.ID, e.URI); err != nil {
+			return err
+		}
+	}
+	for _, e := range catalog.URIs {
+		if err := c.add(base, e.Name, e.URI); err != nil {
+			return err
+		}
+	}
+	for _, e := range catalog.Publics {
+		if err := c.add(base, e.ID, e.URI); err != nil {
+			return err
+		}
+	}
+	for _, e := range catalog.RewriteSystems {
+		c.rewrites = append(c.rewrites, catalogRewrite{prefix: e.Prefix, rewrite: e.Rewrite, base: base})
+	}
+	for _, e := range catalog.RewriteURIs {
+		c.rewrites = append(c.rewrites, catalogRewrite{prefix: e.Prefix, rewrite: e.Rewrite, base: base})
+	}
+	for i := range catalog.Groups {
+		if err := c.addOASIS(base, &catalog.Groups[i], loaded); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range catalog.NextCatalogs {
+		loc, err := base.Parse(e.Catalog)
+		if err != nil {
+			return err
+		}
+		if loaded[loc.canonical()] {
+			continue
+		}
+		next, err := loadCatalog(loc, loaded)
+		if err != nil {
+			return err
+		}
+		c.next = append(c.next, next)
+	}
+	return nil
+}
+
+func (c *Catalog) add(base *Location, key, ref string) error {
+	if _, ok := c.entries[key]; ok {
+		// The first matching entry wins.
+		return nil
+	}
+	loc, err := base.Parse(ref)
+	if err != nil {
+		return err
+	}
+	c.entries[key] = loc
+	return nil
+}
+
+// resolve returns the location mapped to ref, which is either the location of
+// a document or a namespace. Exact entries take precedence over rewrites, of
+// which the one with the longest prefix is used. Catalogs chained with
+// nextCatalog are consulted last.
+func (c *Catalog) resolve(ref string) (*Location, bool) {
+	if c == nil || ref == "" {
+		return nil, false
+	}
+
+	if loc, ok := c.entries[ref]; ok {
+		return loc, true
+	}
+
+	var match *catalogRewrite
+	for i, r := range c.rewrites {
+		if strings.HasPrefix(ref, r.prefix) && (match == nil || len(r.prefix) > len(match.prefix)) {
+			match = &c.rewrites[i]
+		}
+	}
+	if match != nil {
+		loc, err := match.base.Parse(match.rewrite + strings.TrimPrefix(ref, match.prefix))
+		if err == nil {
+			return loc, true
+		}
+	}
+
+	for _, next := range c.next {
+		if loc, ok := next.resolve(ref); ok {
+			return loc, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,57 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestCatalog_resolve(t *testing.T) {
+	dir := t.TempDir()
+	catalog := `<catalog xmlns="urn:oasis:names:tc:entity:xmlns:xml:catalog">
+  <system systemId="http://example.org/schemas/v1/special.xsd" uri="special.xsd"/>
+  <rewriteSystem systemIdStartString="http://example.org/" rewritePrefix="mirror/"/>
+  <rewriteURI uriStartString="http://example.org/schemas/" rewritePrefix="file:///opt/schemas/"/>
+  <uri name="urn:example:types" uri="types/types.xsd"/>
+</catalog>`
+	file := filepath.Join(dir, "catalog.xml")
+	if err := ioutil.WriteFile(file, []byte(catalog), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadCatalog(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{"http://example.org/schemas/v1/special.xsd", filepath.Join(dir, "special.xsd")},
+		{"http://example.org/schemas/v1/common.xsd", filepath.FromSlash("/opt/schemas/v1/common.xsd")},
+		{"http://example.org/other.xsd", filepath.Join(dir, "mirror", "other.xsd")},
+		{"urn:example:types", filepath.Join(dir, "types", "types.xsd")},
+		{"http://example.com/other.xsd", ""},
+	}
+	for _, test := range tests {
+		loc, ok := c.resolve(test.ref)
+		if test.expected == "" {
+			if ok {
+				t.Errorf("%s should not resolve, got %s", test.ref, loc)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%s should resolve to %s", test.ref, test.expected)
+			continue
+		}
+		if !loc.isFile() || loc.String() != test.expected {
+			t.Errorf("%s resolved to %s, wanted %s", test.ref, loc, test.expected)
+		}
+	}
+}
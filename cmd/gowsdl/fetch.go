@@ -0,0 +1,129 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	gen "github.com/ParticleHealth/gowsdl"
+)
+
+// loadFlags are the flags controlling how the WSDL and the documents it
+// references are loaded, shared by the commands.
+type loadFlags struct {
+	insecure *bool
+	catalog  *string
+	cacheDir *string
+	offline  *bool
+
+	header headerFlag
+	user   *string
+	cert   *string
+	key    *string
+	cacert *string
+	proxy  *string
+}
+
+// headerFlag collects the headers given with repeated -H flags.
+type headerFlag http.Header
+
+func (h headerFlag) String() string {
+	return ""
+}
+
+func (h headerFlag) Set(value string) error {
+	i := strings.Index(value, ":")
+	if i <= 0 {
+		return fmt.Errorf("header %q should be formatted as Name: value", value)
+	}
+	http.Header(h).Add(strings.TrimSpace(value[:i]), strings.TrimSpace(value[i+1:]))
+	return nil
+}
+
+func newLoadFlags(fs *flag.FlagSet) *loadFlags {
+	f := &loadFlags{
+		insecure: fs.Bool("i", false, "Skips TLS Verification"),
+		catalog:  fs.String("catalog", "", "OASIS XML catalog or JSON mapping file resolving schema locations and namespaces to local files"),
+		cacheDir: fs.String("cache-dir", "", "Directory where downloaded documents are cached, e.g. "+gen.DefaultCacheDir()+". Documents are downloaded on every run by default"),
+		offline:  fs.Bool("offline", false, "Only read remote documents from the cache set by -cache-dir"),
+		header:   headerFlag{},
+		user:     fs.String("user", "", "User and password for basic authentication, as user:password. The password defaults to $GOWSDL_PASSWORD"),
+		cert:     fs.String("cert", "", "PEM file of the client certificate for mutual TLS"),
+		key:      fs.String("key", "", "PEM file of the client certificate key"),
+		cacert:   fs.String("cacert", "", "PEM file of additional certificate authorities to trust"),
+		proxy:    fs.String("proxy", "", "URL of the HTTP proxy, defaults to $HTTPS_PROXY and $HTTP_PROXY"),
+	}
+	fs.Var(f.header, "H", "Header sent when downloading documents, as \"Name: value\". May be repeated")
+	return f
+}
+
+// options returns the generator options set by the flags.
+func (f *loadFlags) options() ([]gen.Option, error) {
+	opts := []gen.Option{
+		gen.WithInsecureSkipVerify(*f.insecure),
+		gen.WithCacheDir(*f.cacheDir),
+		gen.WithOffline(*f.offline),
+	}
+
+	if *f.catalog != "" {
+		c, err := gen.LoadCatalog(*f.catalog)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, gen.WithCatalog(c))
+	}
+
+	config := gen.FetchConfig{
+		Header:     http.Header(f.header),
+		ClientCert: *f.cert,
+		ClientKey:  *f.key,
+		CACert:     *f.cacert,
+		Proxy:      *f.proxy,
+	}
+	if *f.user != "" {
+		config.Username = *f.user
+		config.Password = os.Getenv("GOWSDL_PASSWORD")
+		if i := strings.Index(*f.user, ":"); i >= 0 {
+			config.Username, config.Password = (*f.user)[:i], (*f.user)[i+1:]
+		}
+	}
+	return append(opts, gen.WithFetchConfig(config)), nil
+}
+
+func fetch(args []string) {
+	fs := flag.NewFlagSet("fetch", flag.ExitOnError)
+	out := fs.String("o", "wsdl", "Directory where the WSDL and the documents it references are saved")
+	load := newLoadFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s fetch [options] myservice.wsdl\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	opts, err := load.options()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	gowsdl, err := gen.New(fs.Arg(0), opts...)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	wsdl, err := gowsdl.Snapshot(*out)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	log.Println("Saved", wsdl, "👍")
+}
@@ -0,0 +1,45 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	gen "github.com/ParticleHealth/gowsdl"
+)
+
+func generate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	configFile := fs.String("config", "gowsdl.yaml", "Configuration file listing the WSDLs to generate and their packages")
+	load := newLoadFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s generate [options]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	config, err := gen.LoadConfig(*configFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	opts, err := load.options()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	files, err := config.Generate(opts...)
+	out := config.OutputDir()
+	writeFiles(out, files, err)
+
+	log.Println("Generated", len(files), "files in", out, "👍")
+}
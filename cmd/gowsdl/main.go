@@ -13,16 +13,120 @@ Usage: gowsdl [options] myservice.wsdl
   -p string
         Package under which code will be generated (default "myservice")
   -v    Shows gowsdl version
+  -strict
+        Fail on warnings, such as skipped messages or unresolved references
+  -soap-import string
+        Import path of the SOAP runtime used by the generated code (default "github.com/ParticleHealth/gowsdl/soap")
+  -catalog string
+        OASIS XML catalog or JSON mapping file resolving schema locations and namespaces to local files
+  -cache-dir string
+        Directory where downloaded documents are cached, e.g. ~/.cache/gowsdl. Documents are downloaded on every run by default
+  -offline
+        Only read remote documents from the cache set by -cache-dir
+  -H value
+        Header sent when downloading documents, as "Name: value". May be repeated
+  -user string
+        User and password for basic authentication, as user:password. The password defaults to $GOWSDL_PASSWORD
+  -cert string
+        PEM file of the client certificate for mutual TLS
+  -key string
+        PEM file of the client certificate key
+  -cacert string
+        PEM file of additional certificate authorities to trust
+  -proxy string
+        URL of the HTTP proxy, defaults to $HTTPS_PROXY and $HTTP_PROXY
+  -config string
+        YAML or JSON configuration file mapping XML Schema types to existing Go types
+  -templates string
+        Directory of templates overriding the blocks the Go code is rendered with, one BlockName.tmpl file per block
+  -plugin value
+        Command run as a code generation backend, reading the model as JSON from stdin. May be repeated
+  -naming string
+        How identifiers are derived from XML names: go, camel cased with initialisms, or legacy (default "go")
+  -initialisms string
+        Comma separated initialisms upper cased by the go naming, in addition to golint's, e.g. EPC,SKU
+  -layout string
+        Layout of the client: single, one file, or split, one file per schema namespace and per port type (default "single")
+  -server
+        Generate the server, in the output file name prefixed with "server" (default true)
+  -include value
+        Generate only the operations matching the pattern, a glob or a /regexp/. May be repeated
+  -exclude value
+        Don't generate the operations matching the pattern, a glob or a /regexp/. May be repeated
+
+Usage: gowsdl fetch [options] myservice.wsdl
+  -o string
+        Directory where the WSDL and the documents it references are saved (default "wsdl")
+
+Usage: gowsdl generate [options]
+  -config string
+        Configuration file listing the WSDLs to generate and their packages (default "gowsdl.yaml")
+
+The configuration file maps XML Schema types, built-in or declared by the
+schemas, to existing Go types used instead of the generated ones, e.g.
+xs:decimal to decimal.Decimal. See gowsdl.Config.
+
+Templates override named blocks of the templates the Go code is rendered
+with, such as Tag to add struct tags or Methods to add methods to every type.
+The directory holds one file per block, e.g. Tag.tmpl. See gowsdl.Templates.
+
+Identifiers follow the Go conventions: names are camel cased across
+separators and initialisms upper cased, e.g. CustomerID for customer_id or
+customerId. -initialisms adds initialisms to golint's, and -naming legacy keeps
+the names of the previous versions. See gowsdl.GoNaming.
+
+With -layout split, the client is generated in several files of the package
+directory: the output file declares the helper types, and every schema
+namespace gets a file of types, e.g. example_com_stockquote_xsd_types.go, and
+every port type a file of operations, e.g. stock_quote_port_type_service.go.
+-server=false skips the server.
+
+The -include and -exclude patterns select the operations generated, and only
+the types their messages reach are generated with them. A glob matches the
+operation name, e.g. "Describe*", or portType/operation when it contains a
+slash; a pattern between slashes is a regular expression matched against
+portType/operation, e.g. /^EC2PortType/Describe(Instances|Volumes)$/. See
+gowsdl.OperationFilter.
+
+Plugins generate other files, such as stubs in other languages or
+documentation, from the same model as the Go code. A plugin is a command
+reading {"version": 1, "model": {...}} from its standard input and writing
+{"files": [{"name": "...", "content": "..."}]} to its standard output. The
+files are written to the package directory. See gowsdl.ExecBackend and
+example/plugin.
+
+The generate command generates a package per WSDL listed by a configuration
+file, each with its own options. The types of the schema documents several
+WSDLs import from the same location are generated once, into a common package
+the others import:
+
+	output: gen
+	common:
+	  package: common
+	  import: example.com/app/gen/common
+	inputs:
+	  - wsdl: wsdl/billing.wsdl
+	    package: billing
+	  - wsdl: wsdl/shipping.wsdl
+	    package: shipping
+	    server: true
+
+See gowsdl.Config.
+
+The fetch command snapshots a WSDL and every WSDL and XSD document it
+references into a directory, rewriting the references to relative paths, so
+the snapshot can be committed and generated from offline.
 
 Features
 
-Supports only Document/Literal wrapped services, which are WS-I (http://ws-i.org/) compliant.
+Supports Document/Literal wrapped services, which are WS-I (http://ws-i.org/) compliant,
+as well as RPC/Literal and RPC/Encoded services.
 
 Attempts to generate idiomatic Go code as much as possible.
 
-Supports WSDL 1.1, XML Schema 1.0, SOAP 1.1.
+Supports WSDL 1.1, XML Schema 1.0, SOAP 1.1 and SOAP 1.2.
 
-Resolves external XML Schemas
+Resolves external XML Schemas, optionally through an XML catalog mapping them to local files.
 
 Supports providing WSDL HTTP URL as well as a local WSDL file.
 
@@ -32,30 +136,27 @@ UDDI.
 
 TODO
 
-Add support for filters to allow the user to change the generated code.
-
 If WSDL file is local, resolve external XML schemas locally too instead of failing due to not having a URL to download them from.
 
 Resolve XSD element references.
 
 Support for generating namespaces.
 
-Make code generation agnostic so generating code to other programming languages is feasible through plugins.
-
 */
 
 package main
 
 import (
-	"bytes"
+	"errors"
 	"flag"
 	"fmt"
-	"go/format"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
-	gen "github.com/hooklift/gowsdl"
+	gen "github.com/ParticleHealth/gowsdl"
 )
 
 // Version is initialized in compilation time by go build.
@@ -68,18 +169,43 @@ var vers = flag.Bool("v", false, "Shows gowsdl version")
 var pkg = flag.String("p", "myservice", "Package under which code will be generated")
 var outFile = flag.String("o", "myservice.go", "File where the generated code will be saved")
 var dir = flag.String("d", "./", "Directory under which package directory will be created")
-var insecure = flag.Bool("i", false, "Skips TLS Verification")
 var makePublic = flag.Bool("make-public", true, "Make the generated types public/exported")
+var strict = flag.Bool("strict", false, "Fail on warnings, such as skipped messages or unresolved references")
+var soapImport = flag.String("soap-import", "github.com/ParticleHealth/gowsdl/soap", "Import path of the SOAP runtime used by the generated code")
+var configFile = flag.String("config", "", "YAML or JSON configuration file mapping XML Schema types to existing Go types")
+var templatesDir = flag.String("templates", "", "Directory of templates overriding the blocks the Go code is rendered with, one BlockName.tmpl file per block")
+var load = newLoadFlags(flag.CommandLine)
+var naming = flag.String("naming", "go", "How identifiers are derived from XML names: go, camel cased with initialisms, or legacy")
+var initialisms = flag.String("initialisms", "", "Comma separated initialisms upper cased by the go naming, in addition to golint's, e.g. EPC,SKU")
+var layout = flag.String("layout", "single", "Layout of the client: single, one file, or split, one file per schema namespace and per port type")
+var server = flag.Bool("server", true, "Generate the server, in the output file name prefixed with \"server\"")
+var plugins pluginFlag
+var include, exclude patternFlag
 
 func init() {
+	flag.Var(&plugins, "plugin", "Command run as a code generation backend, reading the model as JSON from stdin. May be repeated")
+	flag.Var(&include, "include", "Generate only the operations matching the pattern, a glob or a /regexp/. May be repeated")
+	flag.Var(&exclude, "exclude", "Don't generate the operations matching the pattern, a glob or a /regexp/. May be repeated")
+
 	log.SetFlags(0)
 	log.SetOutput(os.Stdout)
 	log.SetPrefix("🍀  ")
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "fetch" {
+		fetch(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		generate(os.Args[2:])
+		return
+	}
+
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [options] myservice.wsdl\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s fetch [options] myservice.wsdl\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s generate [options]\n", os.Args[0])
 		flag.PrintDefaults()
 	}
 
@@ -103,59 +229,124 @@ func main() {
 	}
 
 	// load wsdl
-	gowsdl, err := gen.NewGoWSDL(wsdlPath, *pkg, *insecure, *makePublic)
+	opts, err := load.options()
 	if err != nil {
 		log.Fatalln(err)
 	}
-
-	// generate code
-	gocode, err := gowsdl.Start()
+	var extra []string
+	if *initialisms != "" {
+		extra = strings.Split(*initialisms, ",")
+	}
+	namingStyle, err := gen.ParseNaming(*naming, extra)
 	if err != nil {
 		log.Fatalln(err)
 	}
-
-	pkg := filepath.Join(*dir, *pkg)
-	err = os.Mkdir(pkg, 0744)
-
-	file, err := os.Create(filepath.Join(pkg, *outFile))
+	opts = append(opts,
+		gen.WithNaming(namingStyle),
+		gen.WithPackage(*pkg),
+		gen.WithExportAllTypes(*makePublic),
+		gen.WithSOAPImport(*soapImport),
+		gen.WithStrict(*strict),
+		gen.WithOperationFilter(gen.OperationFilter{Include: include, Exclude: exclude}),
+	)
+	if *configFile != "" {
+		config, err := gen.LoadConfig(*configFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		types, err := config.TypeMap()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		opts = append(opts, gen.WithTypeMap(types))
+	}
+	gowsdl, err := gen.New(wsdlPath, opts...)
 	if err != nil {
 		log.Fatalln(err)
 	}
-	defer file.Close()
 
-	data := new(bytes.Buffer)
-	data.Write(gocode["header"])
-	data.Write(gocode["types"])
-	data.Write(gocode["operations"])
-	data.Write(gocode["soap"])
+	var templates gen.Templates
+	if *templatesDir != "" {
+		templates, err = gen.LoadTemplates(*templatesDir)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
 
-	// go fmt the generated code
-	source, err := format.Source(data.Bytes())
+	clientLayout, err := gen.ParseLayout(*layout)
 	if err != nil {
-		file.Write(data.Bytes())
 		log.Fatalln(err)
 	}
+	backends := []gen.Backend{gen.GoClient{File: *outFile, Templates: templates, Layout: clientLayout}}
+	if *server {
+		backends = append(backends, gen.GoServer{File: "server" + *outFile, Templates: templates})
+	}
+	for _, plugin := range plugins {
+		args := strings.Fields(plugin)
+		backends = append(backends, gen.ExecBackend{Command: args[0], Args: args[1:]})
+	}
+
+	// generate code
+	files, err := gowsdl.Generate(backends...)
+	writeFiles(filepath.Join(*dir, *pkg), files, err)
 
-	file.Write(source)
+	log.Println("Done 👍")
+}
 
-	// server
-	serverFile, err := os.Create(pkg + "/" + "server" + *outFile)
+// writeFiles writes the generated files under dir, unless generation failed
+// with err. The previous output is then left alone, and the code rendered
+// anyway is written beside it with the .broken suffix so that it can be
+// inspected.
+func writeFiles(dir string, files []*gen.File, err error) {
+	suffix := ""
+	if err != nil {
+		suffix = ".broken"
+	}
+	for _, f := range files {
+		writeFile(filepath.Join(dir, filepath.FromSlash(f.Name))+suffix, f.Content)
+	}
 	if err != nil {
 		log.Fatalln(err)
 	}
-	defer serverFile.Close()
-
-	serverData := new(bytes.Buffer)
-	serverData.Write(gocode["server_header"])
-	serverData.Write(gocode["server_wsdl"])
-	serverData.Write(gocode["server"])
+}
 
-	serverSource, err := format.Source(serverData.Bytes())
-	if err != nil {
-		serverFile.Write(serverData.Bytes())
+// writeFile writes data to file, creating its directory.
+func writeFile(file string, data []byte) {
+	if err := os.MkdirAll(filepath.Dir(file), 0744); err != nil {
+		log.Fatalln(err)
+	}
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
 		log.Fatalln(err)
 	}
-	serverFile.Write(serverSource)
+}
 
-	log.Println("Done 👍")
+// pluginFlag collects the commands given with repeated -plugin flags.
+type pluginFlag []string
+
+func (p *pluginFlag) String() string {
+	return strings.Join(*p, ", ")
+}
+
+func (p *pluginFlag) Set(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("plugin command is empty")
+	}
+	*p = append(*p, value)
+	return nil
+}
+
+// patternFlag collects the operation patterns given with repeated -include or
+// -exclude flags.
+type patternFlag []string
+
+func (p *patternFlag) String() string {
+	return strings.Join(*p, ", ")
+}
+
+func (p *patternFlag) Set(value string) error {
+	if value == "" {
+		return errors.New("operation pattern is empty")
+	}
+	*p = append(*p, value)
+	return nil
 }
@@ -0,0 +1,268 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration file of the generator, usually gowsdl.yaml,
+// written in YAML or JSON:
+//
+//	output: gen
+//	common:
+//	  package: common
+//	  import: example.com/app/gen/common
+//	inputs:
+//	  - wsdl: wsdl/billing.wsdl
+//	    package: billing
+//	  - wsdl: wsdl/shipping.wsdl
+//	    package: shipping
+//	    server: true
+//	    include: [Create*, Track*]
+//	initialisms: [EPC, SKU]
+//	namespaces:
+//	  vendor: http://vendor.example.com/types
+//	types:
+//	  xs:decimal:
+//	    type: decimal.Decimal
+//	    import: github.com/shopspring/decimal
+//	  vendor:Money:
+//	    type: money.Amount
+//	    import: example.com/internal/money
+//
+// Relative paths are resolved against the directory of the configuration
+// file.
+type Config struct {
+	// Output is the directory the packages are generated in.
+	Output string `yaml:"output"`
+	// SOAPImport is the import path of the SOAP runtime used by the generated
+	// code, unless set by the input.
+	SOAPImport string `yaml:"soapImport"`
+	// Common is the package the types of the schemas shared by several
+	// inputs are generated in, once. Without it, each package declares its
+	// own copy.
+	Common *CommonPackage `yaml:"common"`
+	// Inputs are the WSDLs generated by Generate, each in its own package.
+	Inputs []*Input `yaml:"inputs"`
+	// Naming is how the identifiers are derived from the names of the WSDLs,
+	// "go" by default or "legacy", see ParseNaming.
+	Naming string `yaml:"naming"`
+	// Initialisms are upper cased by the go naming, in addition to
+	// CommonInitialisms.
+	Initialisms []string `yaml:"initialisms"`
+
+	// Namespaces declares the prefixes of the QNames in Types. The xs and
+	// xsd prefixes are bound to XML Schema unless declared otherwise.
+	Namespaces map[string]string `yaml:"namespaces"`
+	// Types maps XML Schema types, built-in or declared by the schemas, to
+	// existing Go types, keyed by prefixed name or by {namespace}local name.
+	Types map[string]GoType `yaml:"types"`
+
+	// dir is the directory of the configuration file.
+	dir string
+}
+
+// Input is a WSDL of a Config and the options of the package generated for
+// it.
+type Input struct {
+	// WSDL is the path or URL of the WSDL.
+	WSDL string `yaml:"wsdl"`
+	// Package is the name of the generated package.
+	Package string `yaml:"package"`
+	// Dir is the directory of the package within the output directory, the
+	// name of the package by default.
+	Dir string `yaml:"dir"`
+	// File is the name of the client file, the name of the package with the
+	// .go extension by default.
+	File string `yaml:"file"`
+	// Server generates the server too, in the client file name prefixed with
+	// "server".
+	Server bool `yaml:"server"`
+	// Layout is how the client is laid out in files, "single" or "split",
+	// see GoClient.
+	Layout     Layout `yaml:"layout"`
+	SOAPImport string `yaml:"soapImport"`
+	Strict     bool   `yaml:"strict"`
+	// Catalog is the XML catalog or JSON mapping file used to load the WSDL,
+	// see LoadCatalog.
+	Catalog string `yaml:"catalog"`
+	// Templates is the directory of the template overrides, see
+	// LoadTemplates.
+	Templates string `yaml:"templates"`
+	// Types maps types like Config.Types, for this input only.
+	Types map[string]GoType `yaml:"types"`
+	// Include and Exclude select the operations generated, see
+	// OperationFilter.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// CommonPackage is the package the types of the schemas shared by the inputs
+// of a Config are generated in.
+type CommonPackage struct {
+	// Package is the name of the package, "common" by default.
+	Package string `yaml:"package"`
+	// Dir is the directory of the package within the output directory, the
+	// name of the package by default.
+	Dir string `yaml:"dir"`
+	// Import is the import path of the package.
+	Import string `yaml:"import"`
+}
+
+// GoType is an existing Go type an XML Schema type is mapped to.
+type GoType struct {
+	// Type is the Go type, qualified by the name of its package unless
+	// predeclared, e.g. "decimal.Decimal" or "string".
+	Type string `yaml:"type" json:"type"`
+	// Import is the import path of the package of Type.
+	Import string `yaml:"import,omitempty" json:"import,omitempty"`
+}
+
+// qualifier returns the package name qualifying t, or "" for predeclared
+// types.
+func (t GoType) qualifier() string {
+	name := strings.TrimLeft(t.Type, "*[]")
+	if i := strings.Index(name, "."); i > 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// LoadConfig reads the YAML or JSON configuration file at file.
+func LoadConfig(file string) (*Config, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{dir: filepath.Dir(file)}
+	d := yaml.NewDecoder(bytes.NewReader(data))
+	d.KnownFields(true)
+	if err := d.Decode(c); err != nil {
+		return nil, fmt.Errorf("unable to read config %s: %w", file, err)
+	}
+	return c, nil
+}
+
+// TypeMap resolves the QNames of the type mappings of c.
+func (c *Config) TypeMap() (TypeMap, error) {
+	return c.typeMap(nil)
+}
+
+// typeMap resolves the QNames of the type mappings of c, followed by types.
+func (c *Config) typeMap(types map[string]GoType) (TypeMap, error) {
+	xmlns := map[string]string{"xs": xmlschema11, "xsd": xmlschema11}
+	for prefix, ns := range c.Namespaces {
+		xmlns[prefix] = ns
+	}
+
+	m := make(TypeMap, len(c.Types)+len(types))
+	for _, mappings := range []map[string]GoType{c.Types, types} {
+		for key, t := range mappings {
+			name, err := parseConfigQName(key, xmlns)
+			if err != nil {
+				return nil, err
+			}
+			m[name] = t
+		}
+	}
+	return m, m.check()
+}
+
+// path resolves a path of the configuration file against its directory.
+// URLs and absolute paths are kept as they are.
+func (c *Config) path(p string) string {
+	if p == "" || filepath.IsAbs(p) || strings.Contains(p, "://") {
+		return p
+	}
+	return filepath.Join(c.dir, p)
+}
+
+// parseConfigQName resolves a prefixed or {namespace}local name.
+func parseConfigQName(name string, xmlns map[string]string) (xml.Name, error) {
+	if strings.HasPrefix(name, "{") {
+		if i := strings.Index(name, "}"); i > 0 && i < len(name)-1 {
+			return xml.Name{Space: name[1:i], Local: name[i+1:]}, nil
+		}
+		return xml.Name{}, fmt.Errorf("invalid type name %q", name)
+	}
+
+	r := strings.SplitN(name, ":", 2)
+	if len(r) != 2 || r[1] == "" {
+		return xml.Name{}, fmt.Errorf("type name %q should be prefixed or written {namespace}local", name)
+	}
+	ns, ok := xmlns[r[0]]
+	if !ok {
+		return xml.Name{}, fmt.Errorf("prefix %s of type %s is not declared in namespaces", r[0], name)
+	}
+	return xml.Name{Space: ns, Local: r[1]}, nil
+}
+
+// TypeMap maps the QNames of XML Schema types to the existing Go types used
+// instead of the ones generated.
+type TypeMap map[xml.Name]GoType
+
+// check fails when types are missing or imported inconsistently.
+func (m TypeMap) check() error {
+	imports := make(map[string]string)
+	for name, t := range m {
+		if strings.TrimSpace(t.Type) == "" {
+			return fmt.Errorf("type %s is mapped to no Go type", name.Local)
+		}
+		qualifier := t.qualifier()
+		if t.Import != "" && qualifier == "" {
+			return fmt.Errorf("Go type %s of %s should be qualified by the name of package %s", t.Type, name.Local, t.Import)
+		}
+		if t.Import == "" {
+			continue
+		}
+		if other, ok := imports[qualifier]; ok && other != t.Import {
+			return fmt.Errorf("package name %s is used for both %s and %s", qualifier, other, t.Import)
+		}
+		imports[qualifier] = t.Import
+	}
+	return nil
+}
+
+// lookup returns the Go type name is mapped to. The types of the SOAP
+// encoding namespace are mapped as the XML Schema types they derive from.
+func (m TypeMap) lookup(name xml.Name) (GoType, bool) {
+	if t, ok := m[name]; ok {
+		return t, true
+	}
+	if name.Space == soapEncodingNamespace {
+		t, ok := m[xml.Name{Space: xmlschema11, Local: name.Local}]
+		return t, ok
+	}
+	return GoType{}, false
+}
+
+// Import is a package imported by the generated code.
+type Import struct {
+	// Name is the name the package is imported as, when it differs from the
+	// last element of Path.
+	Name string `json:"name,omitempty"`
+	Path string `json:"path"`
+}
+
+// packages returns the import paths of the packages of the mapped types, by
+// package name.
+func (m TypeMap) packages() map[string]string {
+	packages := make(map[string]string)
+	for _, t := range m {
+		if t.Import != "" {
+			packages[t.qualifier()] = t.Import
+		}
+	}
+	return packages
+}
@@ -0,0 +1,95 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"log"
+	"testing"
+)
+
+func TestTypeMap(t *testing.T) {
+	config, err := LoadConfig("fixtures/typemap/gowsdl.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	types, err := config.TypeMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := New("fixtures/dyndns.wsdl", WithTypeMap(types), WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := g.Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := m.Type("Job")
+	if job == nil {
+		t.Fatal("Job type is missing")
+	}
+	for _, f := range job.Fields {
+		if f.Name == "Price" && f.Type != "decimal.Decimal" {
+			t.Errorf("got Price of type %s, want decimal.Decimal", f.Type)
+		}
+	}
+	if m.Type("ContactInfo") != nil {
+		t.Error("ContactInfo is generated, want it mapped")
+	}
+
+	want := []Import{{Path: "example.com/crm/contact/v2", Name: "contact"}, {Path: "github.com/shopspring/decimal"}}
+	if len(m.Imports) != len(want) {
+		t.Fatalf("got %d imports, want %d", len(m.Imports), len(want))
+	}
+	for i, imp := range m.Imports {
+		if *imp != want[i] {
+			t.Errorf("got import %+v, want %+v", imp, want[i])
+		}
+	}
+
+	code, err := renderGo(m, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := code.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`contact "example.com/crm/contact/v2"`, `"github.com/shopspring/decimal"`, "ContactInfo []*contact.Info"} {
+		if !bytes.Contains(client, []byte(s)) {
+			t.Errorf("client doesn't contain %s", s)
+		}
+	}
+}
+
+func TestConfigTypeMapErrors(t *testing.T) {
+	for _, config := range []*Config{
+		{Types: map[string]GoType{"decimal": {Type: "float64"}}},
+		{Types: map[string]GoType{"tns:Money": {Type: "money.Amount"}}},
+		{Types: map[string]GoType{"xs:decimal": {Type: "Decimal", Import: "github.com/shopspring/decimal"}}},
+		{Types: map[string]GoType{
+			"xs:decimal": {Type: "decimal.Decimal", Import: "github.com/shopspring/decimal"},
+			"xs:double":  {Type: "decimal.Big", Import: "github.com/ericlagergren/decimal"},
+		}},
+	} {
+		if _, err := config.TypeMap(); err == nil {
+			t.Errorf("got no error for %v", config.Types)
+		}
+	}
+
+	config := &Config{Types: map[string]GoType{"{urn:money}Money": {Type: "int64"}}}
+	types, err := config.TypeMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := types[xml.Name{Space: "urn:money", Local: "Money"}]; !ok {
+		t.Errorf("got %v, want {urn:money}Money mapped", types)
+	}
+}
@@ -0,0 +1,211 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Severity tells whether a Diagnostic fails the generation.
+type Severity int
+
+const (
+	// SeverityWarning reports definitions that were skipped or guessed.
+	// Warnings fail the generation in strict mode only.
+	SeverityWarning Severity = iota
+	// SeverityError reports a failed generation.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// DiagnosticCode identifies the kind of problem a Diagnostic reports.
+type DiagnosticCode string
+
+const (
+	// CodeLoad reports a WSDL or XSD document that can't be read or parsed.
+	CodeLoad DiagnosticCode = "load"
+	// CodeWSDLImport reports a wsdl:import that can't be resolved.
+	CodeWSDLImport DiagnosticCode = "wsdl-import"
+	// CodeImportCycle reports wsdl:imports importing each other.
+	CodeImportCycle DiagnosticCode = "import-cycle"
+	// CodeSchemaImport reports an xs:import of a namespace no schema
+	// provides.
+	CodeSchemaImport DiagnosticCode = "schema-import"
+	// CodeNamespaceMismatch reports a schema whose target namespace isn't the
+	// one it is imported for.
+	CodeNamespaceMismatch DiagnosticCode = "namespace-mismatch"
+	// CodeEmptyMessage reports a message without parts, which is skipped.
+	CodeEmptyMessage DiagnosticCode = "empty-message"
+	// CodeSharedRPCMessage reports a message used by several RPC operations.
+	CodeSharedRPCMessage DiagnosticCode = "shared-rpc-message"
+	// CodeUnresolvedType reports a reference to an undeclared type.
+	CodeUnresolvedType DiagnosticCode = "unresolved-type"
+	// CodeUnresolvedElement reports a reference to an undeclared element.
+	CodeUnresolvedElement DiagnosticCode = "unresolved-element"
+	// CodeOperationFilter reports an include pattern of the operation filter
+	// matching no operation.
+	CodeOperationFilter DiagnosticCode = "operation-filter"
+	// CodeSubstitutionGroup reports a struct holding the elements of a
+	// substitution group along with other elements matched regardless of
+	// their name, which the decoder hands to the first field only.
+	CodeSubstitutionGroup DiagnosticCode = "substitution-group"
+	// CodePattern reports a pattern facet the regexp package can't match,
+	// left out of the generated validation.
+	CodePattern DiagnosticCode = "pattern"
+	// CodeTemplate reports a failure to generate code.
+	CodeTemplate DiagnosticCode = "template"
+	// CodeFormat reports generated code that doesn't parse.
+	CodeFormat DiagnosticCode = "format"
+	// CodeBackend reports a Backend failing to generate files.
+	CodeBackend DiagnosticCode = "backend"
+)
+
+// A Diagnostic is a problem found while generating code.
+type Diagnostic struct {
+	Severity Severity
+	Code     DiagnosticCode
+	Pos      Position
+	Message  string
+	// Err is the error causing the diagnostic, if any.
+	Err error
+}
+
+// String formats the diagnostic as "position: severity: message [code]".
+func (d Diagnostic) String() string {
+	return d.format(true)
+}
+
+func (d Diagnostic) format(severity bool) string {
+	var b strings.Builder
+	if pos := d.Pos.String(); pos != "" {
+		b.WriteString(pos + ": ")
+	}
+	if severity {
+		b.WriteString(d.Severity.String() + ": ")
+	}
+	fmt.Fprintf(&b, "%s [%s]", d.Message, d.Code)
+	return b.String()
+}
+
+// DiagnosticError is returned by Start when the generation failed. It holds
+// the errors, and the warnings in strict mode.
+type DiagnosticError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *DiagnosticError) Error() string {
+	if len(e.Diagnostics) == 1 {
+		return e.Diagnostics[0].String()
+	}
+	lines := make([]string, 0, len(e.Diagnostics)+1)
+	lines = append(lines, fmt.Sprintf("%d problems:", len(e.Diagnostics)))
+	for _, d := range e.Diagnostics {
+		lines = append(lines, "\t"+d.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns the error causing the first diagnostic.
+func (e *DiagnosticError) Unwrap() error {
+	for _, d := range e.Diagnostics {
+		if d.Err != nil {
+			return d.Err
+		}
+	}
+	return nil
+}
+
+// diagnostics collects the diagnostics of a generation. Templates are executed
+// concurrently, so it is safe for concurrent use.
+type diagnostics struct {
+	mu   sync.Mutex
+	list []Diagnostic
+	seen map[string]bool
+}
+
+func (ds *diagnostics) add(d Diagnostic) bool {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	key := d.String()
+	if ds.seen[key] {
+		return false
+	}
+	if ds.seen == nil {
+		ds.seen = make(map[string]bool)
+	}
+	ds.seen[key] = true
+	ds.list = append(ds.list, d)
+	return true
+}
+
+func (ds *diagnostics) all() []Diagnostic {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return append([]Diagnostic(nil), ds.list...)
+}
+
+// warnf reports a warning about the document at pos.
+func (g *GoWSDL) warnf(code DiagnosticCode, pos Position, format string, args ...interface{}) {
+	g.report(Diagnostic{Severity: SeverityWarning, Code: code, Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// fail reports err as an error.
+func (g *GoWSDL) fail(code DiagnosticCode, pos Position, err error) {
+	g.report(Diagnostic{Severity: SeverityError, Code: code, Pos: pos, Message: err.Error(), Err: err})
+}
+
+// failWith reports err as an error, or the diagnostics of err when it is a
+// *DiagnosticError.
+func (g *GoWSDL) failWith(code DiagnosticCode, err error) {
+	var de *DiagnosticError
+	if !errors.As(err, &de) {
+		g.fail(code, Position{}, err)
+		return
+	}
+	for _, d := range de.Diagnostics {
+		g.report(d)
+	}
+}
+
+// report logs d unless it was reported already.
+func (g *GoWSDL) report(d Diagnostic) {
+	if !g.diagnostics.add(d) {
+		return
+	}
+	if d.Severity == SeverityError {
+		g.logger.Printf("[ERROR] %s", d.format(false))
+	} else {
+		g.logger.Printf("[WARN] %s", d.format(false))
+	}
+}
+
+// Diagnostics returns the errors and warnings reported by Start.
+func (g *GoWSDL) Diagnostics() []Diagnostic {
+	return g.diagnostics.all()
+}
+
+// failure returns the error Start fails with, if any.
+func (g *GoWSDL) failure() error {
+	var failed []Diagnostic
+	for _, d := range g.diagnostics.all() {
+		if d.Severity == SeverityError || g.strict {
+			failed = append(failed, d)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &DiagnosticError{Diagnostics: failed}
+}
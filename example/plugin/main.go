@@ -0,0 +1,63 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Command plugin is an example of a gowsdl plugin, documenting the services
+// of a WSDL in Markdown:
+//
+//	go build -o gowsdl-docs ./example/plugin
+//	gowsdl -plugin ./gowsdl-docs myservice.wsdl
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ParticleHealth/gowsdl"
+)
+
+func main() {
+	var request gowsdl.ExecRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+		respond(gowsdl.ExecResponse{Error: err.Error()})
+		return
+	}
+	if request.Version != gowsdl.ExecProtocolVersion {
+		respond(gowsdl.ExecResponse{Error: fmt.Sprintf("unsupported protocol version %d", request.Version)})
+		return
+	}
+
+	m := request.Model
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", m.Package)
+	for _, s := range m.Services {
+		fmt.Fprintf(&b, "\n## %s\n\n", s.Name)
+		if s.Doc != "" {
+			fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(s.Doc))
+		}
+		for _, op := range s.Operations {
+			fmt.Fprintf(&b, "- `%s`", op.Name)
+			if op.Input != nil {
+				fmt.Fprintf(&b, " takes `%s`", op.Input.Type)
+			}
+			if op.Output != nil {
+				fmt.Fprintf(&b, " returns `%s`", op.Output.Type)
+			}
+			if doc := strings.TrimSpace(op.Doc); doc != "" {
+				fmt.Fprintf(&b, ": %s", doc)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	respond(gowsdl.ExecResponse{Files: []gowsdl.ExecFile{{Name: m.Package + ".md", Content: b.String()}}})
+}
+
+func respond(response gowsdl.ExecResponse) {
+	if err := json.NewEncoder(os.Stdout).Encode(response); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"time"
 
 	"github.com/ParticleHealth/gowsdl/example/server/gen"
-	"github.com/hooklift/gowsdl/soap"
+	"github.com/ParticleHealth/gowsdl/soap"
 )
 
 var done = make(chan struct{})
@@ -5,7 +5,7 @@ package gen
 import (
 	"context"
 	"encoding/xml"
-	"github.com/hooklift/gowsdl/soap"
+	"github.com/ParticleHealth/gowsdl/soap"
 	"time"
 )
 
@@ -26,14 +26,35 @@ type GetInfo struct {
 
 	// comment
 
-	Id string `xml:"Id,omitempty" json:"Id,omitempty"`
+	Id string `xml:"http://www.mnb.hu/webservices/ Id,omitempty" json:"Id,omitempty"`
 }
 
 type GetInfoResponse struct {
 	XMLName xml.Name `xml:"http://www.mnb.hu/webservices/ GetInfoResponse"`
 
 	// this is a comment
-	GetInfoResult string `xml:"GetInfoResult,omitempty" json:"GetInfoResult,omitempty"`
+	GetInfoResult string `xml:"http://www.mnb.hu/webservices/ GetInfoResult,omitempty" json:"GetInfoResult,omitempty"`
+}
+
+type ElementWithLocalSimpleType string
+
+const (
+
+	// First enum value
+	ElementWithLocalSimpleTypeEnum1 ElementWithLocalSimpleType = "enum1"
+
+	// Second enum value
+	ElementWithLocalSimpleTypeEnum2 ElementWithLocalSimpleType = "enum2"
+)
+
+type StartDate soap.XSDDateTime
+
+func (xdt StartDate) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return soap.XSDDateTime(xdt).MarshalXML(e, start)
+}
+
+func (xdt *StartDate) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return (*soap.XSDDateTime)(xdt).UnmarshalXML(d, start)
 }
 
 type ResponseStatus struct {
@@ -41,9 +62,9 @@ type ResponseStatus struct {
 		Value string `xml:",chardata" json:"-,"`
 
 		Code string `xml:"code,attr,omitempty" json:"code,omitempty"`
-	} `xml:"status,omitempty" json:"status,omitempty"`
+	} `xml:"http://www.mnb.hu/webservices/ status,omitempty" json:"status,omitempty"`
 
-	ResponseCode string `xml:"responseCode,attr,omitempty" json:"responseCode,omitempty"`
+	ResponseCode string `xml:"http://www.mnb.hu/webservices/ responseCode,attr,omitempty" json:"responseCode,omitempty"`
 }
 
 type MNBArfolyamServiceType interface {
@@ -78,6 +78,32 @@ var wsdl = `<?xml version="1.0" encoding="utf-8"?>
           </s:restriction>
         </s:simpleType>
       </s:attribute>
+      <!-- element with local simple type -->
+      <s:element name="elementWithLocalSimpleType">
+        <s:annotation>
+          <s:documentation>An element with a local simple type declaration including an enumeration.</s:documentation>
+        </s:annotation>
+        <s:simpleType>
+          <s:restriction base="s:string">
+            <s:enumeration value="enum1">
+              <s:annotation>
+                <s:documentation>First enum value</s:documentation>
+              </s:annotation>
+            </s:enumeration>
+            <s:enumeration value="enum2">
+              <s:annotation>
+                <s:documentation>Second enum value</s:documentation>
+              </s:annotation>
+            </s:enumeration>
+          </s:restriction>
+        </s:simpleType>
+      </s:element>
+      <!-- element of type dateTime -->
+      <s:element name="startDate" type="s:dateTime">
+        <s:annotation>
+          <s:documentation>The date and time when the process starts.</s:documentation>
+        </s:annotation>
+      </s:element>
     </s:schema>
   </wsdl:types>
   <wsdl:message name="GetInfoSoapIn">
@@ -114,15 +140,21 @@ var wsdl = `<?xml version="1.0" encoding="utf-8"?>
 
 var WSDLUndefinedError = errors.New("Server was unable to process request. --> Object reference not set to an instance of an object.")
 
+const (
+	soap11EnvelopeNS = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12EnvelopeNS = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// SOAPEnvelopeRequest accepts both SOAP 1.1 and SOAP 1.2 envelopes.
 type SOAPEnvelopeRequest struct {
-	XMLName xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ Envelope"`
+	XMLName xml.Name `xml:"Envelope"`
 	Body    SOAPBodyRequest
 }
 
 type SOAPBodyRequest struct {
-	XMLName xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ Body"`
+	XMLName xml.Name `xml:"Body"`
 
-	GetInfo *GetInfo `xml:,omitempty`
+	GetInfo *GetInfo `xml:",omitempty"`
 }
 
 type SOAPEnvelopeResponse struct {
@@ -136,7 +168,7 @@ type SOAPEnvelopeResponse struct {
 
 func NewSOAPEnvelopResponse() *SOAPEnvelopeResponse {
 	return &SOAPEnvelopeResponse{
-		PrefixSoap: "http://schemas.xmlsoap.org/soap/envelope/",
+		PrefixSoap: soap11EnvelopeNS,
 		PrefixXsd:  "http://www.w3.org/2001/XMLSchema",
 		PrefixXsi:  "http://www.w3.org/2001/XMLSchema-instance",
 	}
@@ -152,9 +184,19 @@ type Fault struct {
 	Detail string `xml:"detail,omitempty"`
 }
 
+// Fault12 is the SOAP 1.2 counterpart of Fault.
+type Fault12 struct {
+	XMLName xml.Name `xml:"soap:Fault"`
+
+	Code   string `xml:"soap:Code>soap:Value"`
+	Reason string `xml:"soap:Reason>soap:Text"`
+	Detail string `xml:"soap:Detail,omitempty"`
+}
+
 type SOAPBodyResponse struct {
 	XMLName xml.Name `xml:"soap:Body"`
 	Fault   *Fault   `xml:",omitempty"`
+	Fault12 *Fault12 `xml:",omitempty"`
 
 	GetInfo *GetInfoResponse `xml:",omitempty"`
 }
@@ -166,7 +208,6 @@ func (service *SOAPBodyRequest) GetInfoFunc(request *GetInfo) (*GetInfoResponse,
 }
 
 func (service *SOAPEnvelopeRequest) call(w http.ResponseWriter, r *http.Request) {
-	w.Header().Add("Content-Type", "text/xml; charset=utf-8")
 	val := reflect.ValueOf(&service.Body).Elem()
 	n := val.NumField()
 	var field reflect.Value
@@ -174,31 +215,42 @@ func (service *SOAPEnvelopeRequest) call(w http.ResponseWriter, r *http.Request)
 	find := false
 
 	if r.Method == http.MethodGet {
+		w.Header().Add("Content-Type", "text/xml; charset=utf-8")
 		w.Write([]byte(wsdl))
 		return
 	}
 
+	soap12 := strings.Contains(r.Header.Get("Content-Type"), "application/soap+xml")
 	resp := NewSOAPEnvelopResponse()
 	defer func() {
 		if r := recover(); r != nil {
-			resp.Body.Fault = &Fault{}
-			resp.Body.Fault.Space = "http://schemas.xmlsoap.org/soap/envelope/"
-			resp.Body.Fault.Code = "soap:Server"
-			resp.Body.Fault.Detail = fmt.Sprintf("%v", r)
-			resp.Body.Fault.String = fmt.Sprintf("%v", r)
+			if soap12 {
+				resp.Body.Fault12 = &Fault12{}
+				resp.Body.Fault12.Code = "soap:Receiver"
+				resp.Body.Fault12.Reason = fmt.Sprintf("%v", r)
+				resp.Body.Fault12.Detail = fmt.Sprintf("%v", r)
+			} else {
+				resp.Body.Fault = &Fault{}
+				resp.Body.Fault.Space = soap11EnvelopeNS
+				resp.Body.Fault.Code = "soap:Server"
+				resp.Body.Fault.Detail = fmt.Sprintf("%v", r)
+				resp.Body.Fault.String = fmt.Sprintf("%v", r)
+			}
+		}
+		if soap12 {
+			resp.PrefixSoap = soap12EnvelopeNS
+			w.Header().Add("Content-Type", "application/soap+xml; charset=utf-8")
+		} else {
+			w.Header().Add("Content-Type", "text/xml; charset=utf-8")
 		}
 		xml.NewEncoder(w).Encode(resp)
 	}()
 
-	header := r.Header.Get("Content-Type")
-	if strings.Index(header, "application/soap+xml") >= 0 {
-		panic("Could not find an appropriate Transport Binding to invoke.")
-	}
-
 	err := xml.NewDecoder(r.Body).Decode(service)
 	if err != nil {
 		panic(err)
 	}
+	soap12 = service.XMLName.Space == soap12EnvelopeNS
 
 	for i := 0; i < n; i++ {
 		field = val.Field(i)
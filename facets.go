@@ -0,0 +1,127 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// facets returns the facets of the restriction r, or nil when it has none.
+// Patterns the regexp package can't match are left out with a warning. Several
+// patterns are alternatives: a value matching any of them is valid.
+func (g *GoWSDL) facets(r *XSDRestriction, pos Position) *Facets {
+	f := &Facets{
+		MinInclusive: r.MinInclusive.Value,
+		MaxInclusive: r.MaxInclusive.Value,
+		Length:       facetLength(r.Length),
+		MinLength:    facetLength(r.MinLength),
+		MaxLength:    facetLength(r.MaxLength),
+	}
+	for _, value := range r.Enumeration {
+		f.Enumeration = append(f.Enumeration, value.Value)
+	}
+	if len(r.Pattern) > 0 {
+		exprs := make([]string, len(r.Pattern))
+		for i, value := range r.Pattern {
+			exprs[i] = value.Value
+		}
+		pattern, err := goPattern(exprs...)
+		if err != nil {
+			g.warnf(CodePattern, pos, "pattern %s is not supported, ignoring it: %v", strings.Join(exprs, " | "), err)
+		}
+		f.Pattern = pattern
+	}
+
+	if len(f.Enumeration) == 0 && f.Pattern == "" && f.MinInclusive == "" && f.MaxInclusive == "" &&
+		f.Length == 0 && f.MinLength == 0 && f.MaxLength == 0 {
+		return nil
+	}
+	return f
+}
+
+func facetLength(v XSDRestrictionValue) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(v.Value))
+	return n
+}
+
+// xsdClassEscapes are the multi-character escapes of XML Schema regular
+// expressions unknown to the regexp package, as the ranges of a class.
+var xsdClassEscapes = map[byte]string{
+	'i': `\p{L}_:`,
+	'c': `\p{L}\p{Nd}._:\-`,
+}
+
+// goPattern translates the XML Schema regular expressions exprs, which match
+// whole values, to an anchored expression of the regexp package matching any
+// of them.
+func goPattern(exprs ...string) (string, error) {
+	var b strings.Builder
+	b.WriteString(`^(?:`)
+	for i, expr := range exprs {
+		if i > 0 {
+			b.WriteByte('|')
+		}
+		if err := writePattern(&b, expr); err != nil {
+			return "", err
+		}
+	}
+	b.WriteString(`)$`)
+
+	pattern := b.String()
+	if _, err := regexp.Compile(pattern); err != nil {
+		return "", err
+	}
+	return pattern, nil
+}
+
+// writePattern writes the XML Schema regular expression expr to b in the
+// syntax of the regexp package.
+func writePattern(b *strings.Builder, expr string) error {
+	class := false
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == '\\' && i+1 < len(expr):
+			i++
+			e := expr[i]
+			ranges, ok := xsdClassEscapes[e|0x20]
+			switch {
+			case !ok:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			case class && e&0x20 == 0:
+				return errors.New("negated escape in a character class")
+			case class:
+				b.WriteString(ranges)
+			case e&0x20 == 0:
+				b.WriteString(`[^` + ranges + `]`)
+			default:
+				b.WriteString(`[` + ranges + `]`)
+			}
+		case class && c == '-' && i+1 < len(expr) && expr[i+1] == '[':
+			return errors.New("character class subtraction")
+		case c == '[':
+			class = true
+			b.WriteByte(c)
+			if i+1 < len(expr) && expr[i+1] == '^' {
+				b.WriteByte('^')
+				i++
+			}
+		case c == ']':
+			class = false
+			b.WriteByte(c)
+		case !class && (c == '^' || c == '$'):
+			// Anchors are ordinary characters in XML Schema.
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGoPattern(t *testing.T) {
+	tests := []struct {
+		pattern, want string
+	}{
+		{`\d{3}`, `^(?:\d{3})$`},
+		{`a|b`, `^(?:a|b)$`},
+		{`\i\c*`, `^(?:[\p{L}_:][\p{L}\p{Nd}._:\-]*)$`},
+		{`[\i-]+`, `^(?:[\p{L}_:-]+)$`},
+		{`\$[^$]`, `^(?:\$[^$])$`},
+		{`^a$`, `^(?:\^a\$)$`},
+	}
+	for _, test := range tests {
+		got, err := goPattern(test.pattern)
+		if err != nil || got != test.want {
+			t.Errorf("goPattern(%q) = %q, %v, want %q", test.pattern, got, err, test.want)
+		}
+	}
+
+	// Several patterns are alternatives.
+	zip, err := goPattern(`\d{5}`, `\d{5}-\d{4}`)
+	if err != nil || zip != `^(?:\d{5}|\d{5}-\d{4})$` {
+		t.Fatalf("goPattern = %q, %v", zip, err)
+	}
+	for _, value := range []string{"12345", "12345-6789"} {
+		if !regexp.MustCompile(zip).MatchString(value) {
+			t.Errorf("%s should match %q", zip, value)
+		}
+	}
+
+	for _, pattern := range []string{`[a-z-[aeiou]]`, `[\I]`, `\p{IsBasicLatin}`} {
+		if _, err := goPattern(pattern); err == nil {
+			t.Errorf("goPattern(%q) should fail", pattern)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var timeout = time.Duration(30 * time.Second)
+
+func dialTimeout(network, addr string) (net.Conn, error) {
+	return net.DialTimeout(network, addr, timeout)
+}
+
+// FetchConfig configures how remote WSDL and XSD documents are downloaded.
+type FetchConfig struct {
+	// Header is added to the requests, e.g. an Authorization header with a
+	// bearer token.
+	Header http.Header
+	// Username and Password are sent with basic authentication when
+	// Username is set.
+	Username string
+	Password string
+	// Hosts are the host names Header and the credentials are sent to. It
+	// defaults to the host of the WSDL, or to every host when the WSDL is
+	// not remote.
+	Hosts []string
+
+	// ClientCert and ClientKey are the PEM files of the certificate
+	// presented to servers requiring mutual TLS.
+	ClientCert string
+	ClientKey  string
+	// CACert is a PEM file of the certificate authorities trusted besides
+	// the system ones.
+	CACert string
+
+	// Proxy is the URL of the HTTP proxy. It defaults to the proxy set by
+	// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	Proxy string
+}
+
+// A Fetcher downloads remote WSDL and XSD documents.
+type Fetcher interface {
+	Fetch(url string) ([]byte, error)
+}
+
+// NewFetcher returns a Fetcher downloading documents as configured by config.
+// It fails when the certificates of config can't be loaded.
+func NewFetcher(config FetchConfig) (Fetcher, error) {
+	return config.fetcher(false, nil)
+}
+
+// fetcher downloads documents as configured by a FetchConfig.
+type fetcher struct {
+	client   *http.Client
+	header   http.Header
+	username string
+	password string
+	// hosts the header and the credentials are sent to, all when nil.
+	hosts map[string]bool
+}
+
+func (c FetchConfig) fetcher(ignoreTLS bool, wsdl *Location) (*fetcher, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: ignoreTLS,
+	}
+
+	if c.ClientCert != "" || c.ClientKey != "" {
+		if c.ClientCert == "" || c.ClientKey == "" {
+			return nil, errors.New("both a client certificate and its key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.CACert != "" {
+		pem, err := ioutil.ReadFile(c.CACert)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", c.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if c.Proxy != "" {
+		u, err := url.Parse(c.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	f := &fetcher{
+		client: &http.Client{Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+			Proxy:           proxy,
+			Dial:            dialTimeout,
+		}},
+		header:   c.Header,
+		username: c.Username,
+		password: c.Password,
+	}
+
+	hosts := c.Hosts
+	if len(hosts) == 0 && wsdl != nil && wsdl.isURL() {
+		hosts = []string{wsdl.u.Hostname()}
+	}
+	if len(hosts) > 0 {
+		f.hosts = make(map[string]bool, len(hosts))
+		for _, host := range hosts {
+			f.hosts[strings.ToLower(host)] = true
+		}
+	}
+	return f, nil
+}
+
+// Fetch downloads the document at url.
+func (f *fetcher) Fetch(url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.hosts == nil || f.hosts[strings.ToLower(req.URL.Hostname())] {
+		for name, values := range f.header {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+		if f.username != "" {
+			req.SetBasicAuth(f.username, f.password)
+		}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("Received response code %d", resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -0,0 +1,104 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestFetchConfig_auth(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "user" || password != "secret" || r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "<definitions/>")
+	}))
+	defer server.Close()
+
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(caCert, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	wsdl, err := ParseLocation(server.URL + "/service.wsdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config FetchConfig
+		ok     bool
+	}{
+		{"untrusted", FetchConfig{Username: "user", Password: "secret"}, false},
+		{"unauthenticated", FetchConfig{CACert: caCert}, false},
+		{"authenticated", FetchConfig{
+			CACert:   caCert,
+			Username: "user",
+			Password: "secret",
+			Header:   http.Header{"X-Api-Key": {"key"}},
+		}, true},
+		{"other host", FetchConfig{
+			CACert:   caCert,
+			Username: "user",
+			Password: "secret",
+			Header:   http.Header{"X-Api-Key": {"key"}},
+			Hosts:    []string{"example.org"},
+		}, false},
+	}
+	for _, test := range tests {
+		f, err := test.config.fetcher(false, wsdl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = f.Fetch(wsdl.String())
+		if test.ok && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("%s: download should fail", test.name)
+		}
+	}
+}
+
+func TestFetchConfig_proxy(t *testing.T) {
+	var requested string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.String()
+		fmt.Fprint(w, "<definitions/>")
+	}))
+	defer proxy.Close()
+
+	f, err := FetchConfig{Proxy: proxy.URL}.fetcher(false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Fetch("http://wsdl.example.invalid/service.wsdl"); err != nil {
+		t.Fatal(err)
+	}
+	if requested != "http://wsdl.example.invalid/service.wsdl" {
+		t.Errorf("proxy got %q", requested)
+	}
+}
+
+func TestFetchConfig_clientCert(t *testing.T) {
+	_, err := FetchConfig{ClientCert: "cert.pem"}.fetcher(false, nil)
+	if err == nil {
+		t.Error("a client certificate without key should be rejected")
+	}
+	_, err = FetchConfig{ClientCert: "missing.pem", ClientKey: "missing.key"}.fetcher(false, nil)
+	if err == nil {
+		t.Error("missing client certificate files should be reported")
+	}
+}
@@ -0,0 +1,384 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// OperationFilter selects the operations generated. Only the types reachable
+// from the messages of the selected operations are generated with them.
+//
+// Patterns are matched against "portType/operation". A pattern between
+// slashes is a regular expression, e.g. /Instances$/. Otherwise it is a glob,
+// see path.Match, matching the operation name alone unless it contains a
+// slash, e.g. "Describe*" or "EC2PortType/Describe*".
+type OperationFilter struct {
+	// Include lists the patterns of the operations generated, all of them
+	// when empty.
+	Include []string `yaml:"include"`
+	// Exclude lists the patterns of the operations not generated, even when
+	// included.
+	Exclude []string `yaml:"exclude"`
+}
+
+// operationPattern is a compiled pattern of an OperationFilter.
+type operationPattern struct {
+	text string
+	re   *regexp.Regexp
+}
+
+func compileOperationPattern(text string) (*operationPattern, error) {
+	p := &operationPattern{text: text}
+	if len(text) > 1 && strings.HasPrefix(text, "/") && strings.HasSuffix(text, "/") {
+		re, err := regexp.Compile(text[1 : len(text)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid operation pattern %s: %w", text, err)
+		}
+		p.re = re
+		return p, nil
+	}
+	if _, err := path.Match(text, ""); err != nil {
+		return nil, fmt.Errorf("invalid operation pattern %s: %w", text, err)
+	}
+	return p, nil
+}
+
+func (p *operationPattern) match(portType, operation string) bool {
+	name := portType + "/" + operation
+	if p.re != nil {
+		return p.re.MatchString(name)
+	}
+	if !strings.Contains(p.text, "/") {
+		name = operation
+	}
+	ok, _ := path.Match(p.text, name)
+	return ok
+}
+
+// operationFilter is a compiled OperationFilter.
+type operationFilter struct {
+	include, exclude []*operationPattern
+}
+
+func (f OperationFilter) compile() (*operationFilter, error) {
+	if len(f.Include) == 0 && len(f.Exclude) == 0 {
+		return nil, nil
+	}
+
+	c := new(operationFilter)
+	for _, text := range f.Include {
+		p, err := compileOperationPattern(text)
+		if err != nil {
+			return nil, err
+		}
+		c.include = append(c.include, p)
+	}
+	for _, text := range f.Exclude {
+		p, err := compileOperationPattern(text)
+		if err != nil {
+			return nil, err
+		}
+		c.exclude = append(c.exclude, p)
+	}
+	return c, nil
+}
+
+// filterOperations removes the operations the filter doesn't select from the
+// port types, and the port types left without operations.
+func (g *GoWSDL) filterOperations() {
+	matched := make(map[*operationPattern]bool)
+	selected := func(pt *WSDLPortType, op *WSDLOperation) bool {
+		for _, p := range g.filter.exclude {
+			if p.match(pt.Name, op.Name) {
+				return false
+			}
+		}
+		if len(g.filter.include) == 0 {
+			return true
+		}
+		included := false
+		for _, p := range g.filter.include {
+			if p.match(pt.Name, op.Name) {
+				matched[p] = true
+				included = true
+			}
+		}
+		return included
+	}
+
+	portTypes := g.wsdl.PortTypes[:0]
+	for _, pt := range g.wsdl.PortTypes {
+		ops := pt.Operations[:0]
+		for _, op := range pt.Operations {
+			if selected(pt, op) {
+				ops = append(ops, op)
+			}
+		}
+		pt.Operations = ops
+		if len(ops) > 0 {
+			portTypes = append(portTypes, pt)
+		}
+	}
+	g.wsdl.PortTypes = portTypes
+
+	for _, p := range g.filter.include {
+		if !matched[p] {
+			g.warnf(CodeOperationFilter, Position{}, "operation pattern %s matches no operation", p.text)
+		}
+	}
+}
+
+// reachable returns the global types and elements reachable from the messages
+// of the operations of the port types, through the types and elements of
+// their parts, and from those through element types and references, base
+// types, item and member types of simple types, groups, attributes, the
+// elements substituting abstract elements and the types derived from complex
+// types.
+func (g *GoWSDL) reachable() map[symbol]bool {
+	r := &reachability{
+		seen:          make(map[symbol]bool),
+		elements:      make(map[xml.Name]*XSDElement),
+		types:         make(map[xml.Name]interface{}),
+		attributes:    make(map[xml.Name]*XSDAttribute),
+		substitutions: g.substitutions,
+		derivations:   g.derivations,
+		schemas:       make(map[interface{}]*XSDSchema),
+	}
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, elm := range schema.Elements {
+			name := xml.Name{Space: schema.TargetNamespace, Local: elm.Name}
+			r.elements[name] = elm
+			r.schemas[elm] = schema
+		}
+		for _, ct := range schema.ComplexTypes {
+			name := xml.Name{Space: schema.TargetNamespace, Local: ct.Name}
+			r.types[name] = ct
+			r.schemas[ct] = schema
+		}
+		for _, st := range schema.SimpleType {
+			name := xml.Name{Space: schema.TargetNamespace, Local: st.Name}
+			r.types[name] = st
+			r.schemas[st] = schema
+		}
+		for _, attr := range schema.Attributes {
+			name := xml.Name{Space: schema.TargetNamespace, Local: attr.Name}
+			r.attributes[name] = attr
+			r.schemas[attr] = schema
+		}
+	}
+
+	for _, message := range g.operationMessages() {
+		msg := g.findMessage(message)
+		if msg == nil {
+			continue
+		}
+		for _, part := range msg.Parts {
+			if part.Element != "" {
+				r.element(parseQName(part.Element, g.wsdl.Xmlns))
+			}
+			if part.Type != "" {
+				r.typ(parseQName(part.Type, g.wsdl.Xmlns))
+			}
+		}
+	}
+	return r.seen
+}
+
+// operationMessages returns the names of the messages exchanged by the
+// operations of the port types: their input, output, faults and headers.
+func (g *GoWSDL) operationMessages() []string {
+	var messages []string
+	for _, pt := range g.wsdl.PortTypes {
+		for _, op := range pt.Operations {
+			messages = append(messages, stripns(op.Input.Message), stripns(op.Output.Message))
+			for _, fault := range op.Faults {
+				messages = append(messages, stripns(fault.Message))
+			}
+
+			_, bop := g.findBindingOperation(op.Name, pt.Name)
+			if bop == nil {
+				continue
+			}
+			for _, headers := range [][]*WSDLSOAPHeader{bop.Input.SOAPHeader, bop.Input.SOAP12Header, bop.Output.SOAPHeader, bop.Output.SOAP12Header} {
+				for _, h := range headers {
+					messages = append(messages, stripns(h.Message))
+				}
+			}
+		}
+	}
+	return messages
+}
+
+// reachability walks the components of the schemas, marking the global ones
+// it reaches.
+type reachability struct {
+	seen       map[symbol]bool
+	elements   map[xml.Name]*XSDElement
+	types      map[xml.Name]interface{}
+	attributes map[xml.Name]*XSDAttribute
+	// substitutions are the elements substituting abstract elements.
+	substitutions map[xml.Name][]substitution
+	// derivations are the types derived from complex types.
+	derivations map[xml.Name][]derivation
+	// schemas maps the global components to the schema declaring them,
+	// whose namespace declarations resolve the names they use.
+	schemas map[interface{}]*XSDSchema
+}
+
+func (r *reachability) element(name xml.Name) {
+	sym := symbol{elementSymbol, name}
+	if r.seen[sym] {
+		return
+	}
+	r.seen[sym] = true
+	if elm, ok := r.elements[name]; ok {
+		r.walkElement(r.schemas[elm], elm)
+	}
+	for _, s := range r.substitutions[name] {
+		r.element(s.name)
+	}
+}
+
+func (r *reachability) typ(name xml.Name) {
+	sym := symbol{typeSymbol, name}
+	if r.seen[sym] {
+		return
+	}
+	r.seen[sym] = true
+	switch t := r.types[name].(type) {
+	case *XSDComplexType:
+		r.walkComplexType(r.schemas[t], t)
+	case *XSDSimpleType:
+		r.walkSimpleType(r.schemas[t], t)
+	}
+	for _, d := range r.derivations[name] {
+		r.typ(d.name)
+	}
+}
+
+func (r *reachability) walkElement(schema *XSDSchema, elm *XSDElement) {
+	if elm.schema != nil {
+		schema = elm.schema
+	}
+	if elm.Ref != "" {
+		r.element(schema.qname(elm.Ref))
+	}
+	if elm.Type != "" {
+		r.typ(schema.qname(elm.Type))
+	}
+	if elm.ComplexType != nil {
+		r.walkComplexType(schema, elm.ComplexType)
+	}
+	if elm.SimpleType != nil {
+		r.walkSimpleType(schema, elm.SimpleType)
+	}
+	for _, group := range elm.Groups {
+		r.walkGroup(schema, group)
+	}
+}
+
+func (r *reachability) walkElements(schema *XSDSchema, elms []*XSDElement) {
+	for _, elm := range elms {
+		r.walkElement(schema, elm)
+	}
+}
+
+func (r *reachability) walkGroup(schema *XSDSchema, group *XSDGroup) {
+	for _, elms := range [][]*XSDElement{group.Sequence, choiceElements(group.Choice), choiceElements(group.SequenceChoices...), group.All} {
+		r.walkElements(schema, elms)
+	}
+}
+
+func (r *reachability) walkComplexType(schema *XSDSchema, ct *XSDComplexType) {
+	r.walkElements(schema, ct.Sequence)
+	r.walkElements(schema, choiceElements(ct.Choice))
+	r.walkElements(schema, choiceElements(ct.SequenceChoices...))
+	r.walkElements(schema, ct.All)
+	r.walkAttributes(schema, ct.Attributes)
+
+	ext := ct.ComplexContent.Extension
+	if ext.Base != "" {
+		r.typ(schema.qname(ext.Base))
+	}
+	r.walkElements(schema, ext.Sequence)
+	r.walkElements(schema, choiceElements(ext.Choice))
+	r.walkElements(schema, choiceElements(ext.SequenceChoices...))
+	r.walkAttributes(schema, ext.Attributes)
+
+	restriction := ct.ComplexContent.Restriction
+	if restriction.Base != "" {
+		r.typ(schema.qname(restriction.Base))
+	}
+	r.walkElements(schema, restriction.Sequence)
+	r.walkElements(schema, choiceElements(restriction.Choice))
+	r.walkElements(schema, choiceElements(restriction.SequenceChoices...))
+	r.walkElements(schema, restriction.All)
+	r.walkAttributes(schema, restriction.Attributes)
+	for _, attr := range restriction.Attributes {
+		if attr.ArrayType != "" {
+			// SOAP encoded arrays declare their items as e.g. tns:Item[].
+			r.typ(schema.qname(strings.TrimSuffix(attr.ArrayType, "[]")))
+		}
+	}
+
+	ext = ct.SimpleContent.Extension
+	if ext.Base != "" {
+		r.typ(schema.qname(ext.Base))
+	}
+	r.walkAttributes(schema, ext.Attributes)
+
+	simple := ct.SimpleContent.Restriction
+	if simple.Base != "" {
+		r.typ(schema.qname(simple.Base))
+	}
+	r.walkAttributes(schema, simple.Attributes)
+}
+
+func (r *reachability) walkSimpleType(schema *XSDSchema, st *XSDSimpleType) {
+	if st.Restriction.Base != "" {
+		r.typ(schema.qname(st.Restriction.Base))
+	}
+	if st.List.ItemType != "" {
+		r.typ(schema.qname(st.List.ItemType))
+	}
+	if st.List.SimpleType != nil {
+		r.walkSimpleType(schema, st.List.SimpleType)
+	}
+	for _, member := range strings.Fields(st.Union.MemberTypes) {
+		r.typ(schema.qname(member))
+	}
+	for _, member := range st.Union.SimpleType {
+		r.walkSimpleType(schema, member)
+	}
+}
+
+func (r *reachability) walkAttributes(schema *XSDSchema, attrs []*XSDAttribute) {
+	for _, attr := range attrs {
+		declaring := schema
+		if attr.schema != nil {
+			declaring = attr.schema
+		}
+		if attr.Ref != "" {
+			if global, ok := r.attributes[declaring.qname(attr.Ref)]; ok {
+				r.walkAttribute(r.schemas[global], global)
+			}
+		}
+		r.walkAttribute(declaring, attr)
+	}
+}
+
+func (r *reachability) walkAttribute(schema *XSDSchema, attr *XSDAttribute) {
+	if attr.Type != "" {
+		r.typ(schema.qname(attr.Type))
+	}
+	if attr.SimpleType != nil {
+		r.walkSimpleType(schema, attr.SimpleType)
+	}
+}
@@ -0,0 +1,89 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+)
+
+func TestOperationFilter(t *testing.T) {
+	filters := map[string]OperationFilter{
+		"glob":           {Include: []string{"DescribeInstances", "Terminate*"}},
+		"qualified glob": {Include: []string{"AmazonEC2PortType/DescribeInstances", "AmazonEC2PortType/TerminateInstances"}},
+		"regexp":         {Include: []string{"/^AmazonEC2PortType/(DescribeInstances|TerminateInstances)$/"}},
+		"exclude":        {Include: []string{"DescribeInstances", "TerminateInstances", "RunInstances"}, Exclude: []string{"Run*"}},
+	}
+	for name, filter := range filters {
+		t.Run(name, func(t *testing.T) {
+			g, err := New("fixtures/ec2.wsdl", WithOperationFilter(filter), WithLogger(log.New(ioutil.Discard, "", 0)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := g.Model()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := m.Service("AmazonEC2PortType")
+			if s == nil {
+				t.Fatal("AmazonEC2PortType service is missing")
+			}
+			var ops []string
+			for _, op := range s.Operations {
+				ops = append(ops, op.Name)
+			}
+			if len(ops) != 2 || ops[0] != "DescribeInstances" || ops[1] != "TerminateInstances" {
+				t.Errorf("got operations %v", ops)
+			}
+
+			// Types of the selected messages and the types they reach, but
+			// nothing else.
+			for _, name := range []string{"DescribeInstances", "DescribeInstancesType", "DescribeInstancesItemType", "ReservationSetType", "TerminateInstancesResponseType"} {
+				if m.Type(name) == nil {
+					t.Errorf("type %s is missing", name)
+				}
+			}
+			for _, name := range []string{"RunInstancesType", "DescribeImagesType", "UnavailableResultSetType"} {
+				if m.Type(name) != nil {
+					t.Errorf("type %s should not be generated", name)
+				}
+			}
+
+			code, err := g.Start()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := code.Client(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestOperationFilterErrors(t *testing.T) {
+	if _, err := New("fixtures/ec2.wsdl", WithOperationFilter(OperationFilter{Include: []string{"/(/"}})); err == nil {
+		t.Error("invalid regexps should fail")
+	}
+	if _, err := New("fixtures/ec2.wsdl", WithOperationFilter(OperationFilter{Exclude: []string{"[a-"}})); err == nil {
+		t.Error("invalid globs should fail")
+	}
+
+	g, err := New("fixtures/ec2.wsdl", WithOperationFilter(OperationFilter{Include: []string{"DescribeInstances", "NoSuchOperation"}}), WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Model(); err != nil {
+		t.Fatal(err)
+	}
+	var warned bool
+	for _, d := range g.Diagnostics() {
+		warned = warned || d.Code == CodeOperationFilter && d.Severity == SeverityWarning
+	}
+	if !warned {
+		t.Errorf("patterns matching no operation should be reported, got %v", g.Diagnostics())
+	}
+}
@@ -1,8 +1,9 @@
 module github.com/ParticleHealth/gowsdl
 
-go 1.15
+go 1.16
 
 require (
 	github.com/hooklift/gowsdl v0.5.0
 	github.com/stretchr/testify v1.6.1
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
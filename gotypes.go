@@ -0,0 +1,544 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// buildTypes returns the Go types of the global components of all schemas,
+// schema after schema: simple types, then elements, then complex types. The
+// RPC wrappers come last. Types mapped to existing Go types, the types of the
+// schemas shared through a common package, and with an operation filter the
+// types the selected operations don't reach, are skipped.
+func (g *GoWSDL) buildTypes() []*Type {
+	defer g.setSchema(g.getSchema())
+	var types []*Type
+	for _, schema := range g.wsdl.Types.Schemas {
+		if g.shared[schema] {
+			continue
+		}
+		g.setSchema(schema)
+
+		for _, st := range schema.SimpleType {
+			qname := xml.Name{Space: schema.TargetNamespace, Local: st.Name}
+			if _, ok := g.typeMap[qname]; ok || !g.isReachable(typeSymbol, qname) {
+				continue
+			}
+			types = append(types, g.simpleType(g.goName(typeSymbol, qname), qname, st))
+		}
+		for _, elm := range schema.Elements {
+			qname := xml.Name{Space: schema.TargetNamespace, Local: elm.Name}
+			if !g.isReachable(elementSymbol, qname) {
+				continue
+			}
+			if t := g.elementType(qname, elm); t != nil {
+				types = append(types, t)
+			}
+		}
+		for _, ct := range schema.ComplexTypes {
+			qname := xml.Name{Space: schema.TargetNamespace, Local: ct.Name}
+			if _, ok := g.typeMap[qname]; ok || !g.isReachable(typeSymbol, qname) {
+				continue
+			}
+			types = append(types, g.complexType(qname, ct))
+		}
+	}
+	return append(types, g.rpcWrappers...)
+}
+
+// isReachable reports whether the global component name of kind is generated:
+// always, unless an operation filter restricts the types to the ones the
+// selected operations reach.
+func (g *GoWSDL) isReachable(kind symbolKind, name xml.Name) bool {
+	return g.filter == nil || g.reachableSymbols[symbol{kind, name}]
+}
+
+// simpleType returns the type named name generated for the simple type st.
+func (g *GoWSDL) simpleType(name string, qname xml.Name, st *XSDSimpleType) *Type {
+	t := &Type{Name: name, Kind: DefinedType, QName: qname, Doc: st.Doc, Pos: st.Pos}
+	switch {
+	case st.List.ItemType != "":
+		t.Underlying = "[]" + removePointerFromType(g.toGoType(st.List.ItemType, false, st.Pos))
+		t.Validated = g.validated(typeSymbol, g.qname(st.List.ItemType))
+	case st.Union.MemberTypes != "" || len(st.Union.SimpleType) > 0:
+		t.Underlying = "string"
+	case st.Restriction.Base != "":
+		t.Underlying = removePointerFromType(g.toGoType(st.Restriction.Base, false, st.Pos))
+		t.Validated = g.validated(typeSymbol, g.qname(st.Restriction.Base))
+		t.Facets = g.facets(&st.Restriction, st.Pos)
+	default:
+		t.Underlying = "interface{}"
+	}
+
+	for _, value := range st.Restriction.Enumeration {
+		t.Enums = append(t.Enums, &Enum{
+			Name:  g.naming.Enum(name, value.Value),
+			Value: value.Value,
+			Doc:   value.Doc,
+		})
+	}
+	return t
+}
+
+// elementType returns the type generated for the global element elm, named
+// qname. Elements of a named type only get a type of their
+// own when the names differ. Abstract elements that other elements substitute
+// become interfaces.
+func (g *GoWSDL) elementType(qname xml.Name, elm *XSDElement) *Type {
+	name := g.goName(elementSymbol, qname)
+
+	if substitutes := g.substitutes(qname); elm.Abstract && len(substitutes) > 0 {
+		return &Type{
+			Name:            name,
+			Kind:            InterfaceType,
+			QName:           qname,
+			Doc:             elm.Doc,
+			Pos:             elm.Pos,
+			Substitutes:     substitutes,
+			GroupName:       g.symbols.names[symbol{groupSymbol, qname}],
+			SubstitutesName: g.symbols.names[symbol{substitutesSymbol, qname}],
+		}
+	}
+
+	if elm.Type == "" {
+		if elm.ComplexType != nil {
+			t := &Type{
+				Name:    name,
+				Kind:    StructType,
+				QName:   qname,
+				Doc:     elm.Doc,
+				Pos:     elm.Pos,
+				XMLName: qname,
+			}
+			t.Fields, t.Choices = g.contentFields(elm.ComplexType, true)
+			return t
+		}
+		if elm.SimpleType != nil {
+			t := g.simpleType(name, qname, elm.SimpleType)
+			t.Pos = elm.Pos
+			return t
+		}
+		return nil
+	}
+
+	goType := removePointerFromType(g.toGoType(elm.Type, elm.Nillable, elm.Pos))
+	if goType == name {
+		return nil
+	}
+	return &Type{
+		Name:       name,
+		Kind:       DefinedType,
+		QName:      qname,
+		Doc:        elm.Doc,
+		Pos:        elm.Pos,
+		Underlying: goType,
+		Validated:  g.validated(typeSymbol, g.qname(elm.Type)),
+	}
+}
+
+// complexType returns the type generated for the global complex type ct, named
+// qname.
+func (g *GoWSDL) complexType(qname xml.Name, ct *XSDComplexType) *Type {
+	t := &Type{
+		Name:  g.goName(typeSymbol, qname),
+		QName: qname,
+		Pos:   ct.Pos,
+	}
+
+	if len(ct.SimpleContent.Extension.Attributes) == 0 && g.toGoType(ct.SimpleContent.Extension.Base, false, ct.Pos) == "string" {
+		t.Kind = DefinedType
+		t.Underlying = "string"
+		return t
+	}
+
+	if itemType := soapArrayItemType(ct); itemType != "" {
+		t.Kind = ArrayType
+		t.Underlying = "[]" + g.toGoType(itemType, false, ct.Pos)
+		t.Item = g.qname(itemType)
+		t.Validated = g.validated(typeSymbol, t.Item)
+		return t
+	}
+
+	t.Kind = StructType
+	// The types derived from it embed it, and would be marshalled as its
+	// element.
+	if element := g.findElementByType(qname); element.Local != ct.Name && len(g.derivations[t.QName]) == 0 {
+		t.XMLName = element
+	}
+	t.Fields, t.Choices = g.contentFields(ct, true)
+	t.Subtypes = g.subtypes(t.QName)
+	if len(t.Subtypes) > 0 {
+		t.AnyName = g.symbols.names[symbol{anySymbol, t.QName}]
+		t.ValueName = g.symbols.names[symbol{valueSymbol, t.QName}]
+		t.HierarchyName = g.symbols.names[symbol{hierarchySymbol, t.QName}]
+	}
+	return t
+}
+
+// contentFields returns the fields of the content of ct and the names of the
+// alternatives of its choices. Wildcards only become fields of the types of
+// global components.
+func (g *GoWSDL) contentFields(ct *XSDComplexType, global bool) ([]*Field, [][]string) {
+	var fields []*Field
+	var choices [][]string
+	switch {
+	case ct.ComplexContent.Extension.Base != "":
+		ext := ct.ComplexContent.Extension
+		baseType := g.toGoType(ext.Base, false, ct.Pos)
+		fields = append(fields, &Field{
+			Name:      strings.TrimPrefix(unqualify(baseType), "*"),
+			Type:      baseType,
+			Embedded:  true,
+			Validated: true,
+		})
+		fields = append(fields, g.elementFields(ext.Sequence)...)
+		fields = append(fields, g.choiceFields(&choices, ext.Choice)...)
+		fields = append(fields, g.choiceFields(&choices, ext.SequenceChoices...)...)
+		fields = append(fields, g.attributeFields(ext.Attributes)...)
+
+	case ct.SimpleContent.Extension.Base != "":
+		ext := ct.SimpleContent.Extension
+		fields = append(fields, &Field{
+			Name:      "Value",
+			Type:      g.toGoType(ext.Base, false, ct.Pos),
+			Tag:       `xml:",chardata" json:"-,"`,
+			Chardata:  true,
+			Validated: g.validated(typeSymbol, g.qname(ext.Base)),
+		})
+		fields = append(fields, g.attributeFields(ext.Attributes)...)
+
+	case ct.ComplexContent.Restriction.Base != "" && soapArrayItemType(ct) == "":
+		// The restriction restates the elements of the base type it keeps.
+		r := ct.ComplexContent.Restriction
+		fields = append(fields, g.elementFields(r.Sequence)...)
+		fields = append(fields, g.choiceFields(&choices, r.Choice)...)
+		fields = append(fields, g.choiceFields(&choices, r.SequenceChoices...)...)
+		fields = append(fields, g.elementFields(r.All)...)
+		fields = append(fields, g.restrictedAttributeFields(r.Base, r.Attributes, make(map[*XSDComplexType]bool))...)
+
+	case ct.SimpleContent.Restriction.Base != "":
+		r := ct.SimpleContent.Restriction
+		fields = append(fields, &Field{
+			Name:     "Value",
+			Type:     g.simpleContentType(g.qname(r.Base), ct.Pos, make(map[*XSDComplexType]bool)),
+			Tag:      `xml:",chardata" json:"-,"`,
+			Chardata: true,
+			Facets:   g.facets(&r.XSDRestriction, ct.Pos),
+		})
+		fields = append(fields, g.restrictedAttributeFields(r.Base, r.Attributes, make(map[*XSDComplexType]bool))...)
+
+	default:
+		fields = append(fields, g.elementFields(ct.Sequence)...)
+		if global {
+			for range ct.Any {
+				fields = append(fields, &Field{
+					Name: "Items",
+					Type: "[]string",
+					Tag:  `xml:",any" json:"items,omitempty"`,
+					Any:  true,
+				})
+			}
+		}
+		fields = append(fields, g.choiceFields(&choices, ct.Choice)...)
+		fields = append(fields, g.choiceFields(&choices, ct.SequenceChoices...)...)
+		fields = append(fields, g.elementFields(ct.All)...)
+		fields = append(fields, g.attributeFields(ct.Attributes)...)
+	}
+
+	var groups, wildcards int
+	for _, f := range fields {
+		switch {
+		case f.Group:
+			groups++
+		case f.Any:
+			wildcards++
+		}
+	}
+	if groups > 0 && groups+wildcards > 1 {
+		g.warnf(CodeSubstitutionGroup, ct.Pos, "complex type has %d fields decoding any element, only the first one is decoded", groups+wildcards)
+	}
+	return fields, choices
+}
+
+func (g *GoWSDL) elementFields(elms []*XSDElement) []*Field {
+	fields := make([]*Field, 0, len(elms))
+	for _, elm := range elms {
+		fields = append(fields, g.elementField(elm))
+	}
+	return fields
+}
+
+// choiceFields returns the fields of the alternatives of choices, which may be
+// nil, none of which is required. The names of the alternatives of each choice
+// occurring once are added to names, as only one of them may be set. The
+// alternatives of a repeated choice may all occur, as many times as the choice,
+// and are repeated.
+func (g *GoWSDL) choiceFields(names *[][]string, choices ...*XSDChoice) []*Field {
+	var fields []*Field
+	for _, choice := range choices {
+		if choice == nil {
+			continue
+		}
+		repeated := choice.MaxOccurs == "unbounded" || occurs(choice.MaxOccurs) > 1
+		alternatives := make([]string, 0, len(choice.Elements))
+		for _, elm := range choice.Elements {
+			if repeated {
+				c := *elm
+				c.MaxOccurs = "unbounded"
+				elm = &c
+			}
+			f := g.elementField(elm)
+			f.MinOccurs, f.Required = 0, false
+			fields = append(fields, f)
+			alternatives = append(alternatives, f.Name)
+		}
+		if !repeated && len(alternatives) > 1 {
+			*names = append(*names, alternatives)
+		}
+	}
+	return fields
+}
+
+// elementField returns the field generated for the local element or element
+// reference elm.
+func (g *GoWSDL) elementField(elm *XSDElement) *Field {
+	if elm.schema != nil {
+		defer g.setSchema(g.getSchema())
+		g.setSchema(elm.schema)
+	}
+	f := &Field{MinOccurs: occurs(elm.MinOccurs), MaxOccurs: occurs(elm.MaxOccurs)}
+	f.Repeated = elm.MaxOccurs == "unbounded" || f.MaxOccurs > 1
+	f.Required = f.MinOccurs > 0
+	slice := ""
+	if f.Repeated {
+		slice = "[]"
+	}
+
+	if elm.Ref != "" {
+		f.XMLName = xml.Name{Space: g.elementNS(elm), Local: removeNS(elm.Ref)}
+		f.Name = g.fieldName(f.XMLName.Local)
+		f.Type = slice + g.toGoElementType(elm.Ref, elm.Nillable, elm.Pos)
+		f.Tag = fieldTag(f.XMLName, false)
+		f.Validated = true
+		if head := g.currentSchema.qname(elm.Ref); len(g.substitutes(head)) > 0 {
+			// The elements of the group are told apart by name, any
+			// element being handed to the group.
+			f.Group = true
+			f.Type = g.symbols.names[symbol{groupSymbol, head}]
+			f.Tag = fmt.Sprintf(`xml:",any,omitempty" json:"%s,omitempty"`, f.XMLName.Local)
+		}
+		return f
+	}
+
+	f.XMLName = xml.Name{Space: g.elementNS(elm), Local: elm.Name}
+	f.Tag = fieldTag(f.XMLName, false)
+	f.Doc = elm.Doc
+
+	switch {
+	case elm.Type != "":
+		f.Name = g.fieldName(elm.Name)
+		f.Type = slice + g.toGoType(elm.Type, elm.Nillable, elm.Pos)
+		f.Validated = g.validated(typeSymbol, g.currentSchema.qname(elm.Type))
+		if base := g.currentSchema.qname(elm.Type); len(g.subtypes(base)) > 0 {
+			// Values of the derived types announce their type through
+			// xsi:type.
+			f.Polymorphic = true
+			f.Type = slice + "*" + g.symbols.names[symbol{valueSymbol, base}]
+		}
+	case elm.SimpleType != nil:
+		// Local simple types are never repeated.
+		f.Repeated = false
+		f.Name = g.fieldName(elm.Name)
+		if itemType := elm.SimpleType.List.ItemType; itemType != "" {
+			f.Type = "[]" + g.toGoType(itemType, false, elm.Pos)
+			f.Validated = g.validated(typeSymbol, g.currentSchema.qname(itemType))
+		} else {
+			f.Type = g.toGoType(elm.SimpleType.Restriction.Base, false, elm.Pos)
+			f.Facets = g.facets(&elm.SimpleType.Restriction, elm.Pos)
+			f.Validated = g.validated(typeSymbol, g.currentSchema.qname(elm.SimpleType.Restriction.Base))
+		}
+	default:
+		f.Name = g.fieldName(elm.Name)
+		f.Struct = true
+		if elm.ComplexType != nil {
+			f.Fields, f.Choices = g.contentFields(elm.ComplexType, false)
+		}
+		f.Validated = len(f.Choices) > 0
+		for _, field := range f.Fields {
+			f.Validated = f.Validated || field.Required && field.Omittable() || field.Facets != nil || field.Validated ||
+				field.Repeated && (field.MinOccurs > 0 || field.MaxOccurs > 0)
+		}
+	}
+	return f
+}
+
+// methodNames are the methods generated on the struct types, which their
+// fields can't be named after.
+var methodNames = map[string]bool{"Validate": true}
+
+// fieldName returns the name of the field of the element, attribute or part
+// name, suffixed with an underscore when a generated method has it.
+func (g *GoWSDL) fieldName(name string) string {
+	field := g.naming.Field(name)
+	if methodNames[field] {
+		field += "_"
+	}
+	return field
+}
+
+// occurs returns the number of occurrences minOccurs or maxOccurs allows, 1 by
+// default and 0 when unbounded.
+func occurs(value string) int {
+	switch value {
+	case "":
+		return 1
+	case "unbounded":
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 1
+	}
+	return n
+}
+
+// validated reports whether the Go type of the type or element name has a
+// Validate method, which the builtin and mapped types don't.
+func (g *GoWSDL) validated(kind symbolKind, name xml.Name) bool {
+	_, builtin, _ := g.symbols.lookup(kind, name)
+	return !builtin
+}
+
+func (g *GoWSDL) attributeFields(attrs []*XSDAttribute) []*Field {
+	fields := make([]*Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = append(fields, g.attributeField(attr))
+	}
+	return fields
+}
+
+// attributeField returns the field generated for the local attribute or
+// attribute reference attr.
+func (g *GoWSDL) attributeField(attr *XSDAttribute) *Field {
+	if attr.schema != nil {
+		defer g.setSchema(g.getSchema())
+		g.setSchema(attr.schema)
+	}
+	f := &Field{
+		Name:    g.fieldName(attr.Name),
+		Type:    "string",
+		Doc:     attr.Doc,
+		XMLName: xml.Name{Space: g.attributeNS(attr), Local: attr.Name},
+		Attr:    true,
+	}
+	if attr.Type != "" {
+		f.Type = g.toGoType(attr.Type, false, attr.Pos)
+		f.Validated = g.validated(typeSymbol, g.currentSchema.qname(attr.Type))
+	}
+	if attr.SimpleType != nil && attr.SimpleType.List.ItemType == "" {
+		f.Facets = g.facets(&attr.SimpleType.Restriction, attr.Pos)
+	}
+	f.Required = attr.Use == "required"
+	f.Tag = fieldTag(f.XMLName, true)
+	return f
+}
+
+// restrictedAttributeFields returns the fields of the attributes of a
+// restriction of the complex type base: the ones of base, unless attrs
+// prohibits them, then the ones attrs adds. The attributes attrs declares
+// anew replace the ones of base. seen guards against circular derivations.
+func (g *GoWSDL) restrictedAttributeFields(base string, attrs []*XSDAttribute, seen map[*XSDComplexType]bool) []*Field {
+	restricted := make(map[string]*XSDAttribute, len(attrs))
+	for _, attr := range attrs {
+		restricted[attr.Name] = attr
+	}
+
+	var fields []*Field
+	for _, f := range g.baseAttributeFields(g.qname(base), seen) {
+		attr, ok := restricted[f.XMLName.Local]
+		switch {
+		case !ok:
+			fields = append(fields, f)
+		case attr.Use != "prohibited":
+			fields = append(fields, g.attributeField(attr))
+		}
+		delete(restricted, f.XMLName.Local)
+	}
+	for _, attr := range attrs {
+		if _, ok := restricted[attr.Name]; ok && attr.Use != "prohibited" {
+			fields = append(fields, g.attributeField(attr))
+		}
+	}
+	return fields
+}
+
+// baseAttributeFields returns the fields of the attributes of the global
+// complex type name, including the ones it derives.
+func (g *GoWSDL) baseAttributeFields(name xml.Name, seen map[*XSDComplexType]bool) []*Field {
+	schema, ct := g.findComplexType(name)
+	if ct == nil || seen[ct] {
+		return nil
+	}
+	seen[ct] = true
+	defer g.setSchema(g.getSchema())
+	g.setSchema(schema)
+
+	switch {
+	case ct.ComplexContent.Extension.Base != "":
+		ext := ct.ComplexContent.Extension
+		return append(g.baseAttributeFields(g.qname(ext.Base), seen), g.attributeFields(ext.Attributes)...)
+	case ct.SimpleContent.Extension.Base != "":
+		ext := ct.SimpleContent.Extension
+		return append(g.baseAttributeFields(g.qname(ext.Base), seen), g.attributeFields(ext.Attributes)...)
+	case ct.ComplexContent.Restriction.Base != "":
+		r := ct.ComplexContent.Restriction
+		return g.restrictedAttributeFields(r.Base, r.Attributes, seen)
+	case ct.SimpleContent.Restriction.Base != "":
+		r := ct.SimpleContent.Restriction
+		return g.restrictedAttributeFields(r.Base, r.Attributes, seen)
+	}
+	return g.attributeFields(ct.Attributes)
+}
+
+// simpleContentType returns the Go type of the value of a complex type with
+// simple content derived from the type name, a simple type or another complex
+// type with simple content. An undeclared name is reported at pos.
+func (g *GoWSDL) simpleContentType(name xml.Name, pos Position, seen map[*XSDComplexType]bool) string {
+	schema, ct := g.findComplexType(name)
+	if ct == nil {
+		return removePointerFromType(g.goType(typeSymbol, name, false, pos))
+	}
+	if seen[ct] {
+		return "string"
+	}
+	seen[ct] = true
+	defer g.setSchema(g.getSchema())
+	g.setSchema(schema)
+
+	switch {
+	case ct.SimpleContent.Extension.Base != "":
+		return g.simpleContentType(g.qname(ct.SimpleContent.Extension.Base), ct.Pos, seen)
+	case ct.SimpleContent.Restriction.Base != "":
+		return g.simpleContentType(g.qname(ct.SimpleContent.Restriction.Base), ct.Pos, seen)
+	}
+	return "string"
+}
+
+// fieldTag returns the struct tag of a field marshalled as the element or
+// attribute name.
+func fieldTag(name xml.Name, attr bool) string {
+	xmlName := name.Local
+	if name.Space != "" {
+		xmlName = name.Space + " " + xmlName
+	}
+	if attr {
+		xmlName += ",attr"
+	}
+	return fmt.Sprintf(`xml:"%s,omitempty" json:"%s,omitempty"`, xmlName, name.Local)
+}
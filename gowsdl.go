@@ -6,135 +6,261 @@ package gowsdl
 
 import (
 	"bytes"
-	"crypto/tls"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io/fs"
 	"io/ioutil"
 	"log"
-	"net"
-	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
-	"text/template"
-	"time"
 	"unicode"
 )
 
-const maxRecursion uint8 = 20
-
 // GoWSDL defines the struct for WSDL generator.
 type GoWSDL struct {
-	loc                   *Location
-	rawWSDL               []byte
-	pkg                   string
-	ignoreTLS             bool
-	makePublicFn          func(string) string
-	wsdl                  *WSDL
-	resolvedXSDExternals  map[string]bool
-	currentRecursionLevel uint8
-	currentNamespace      string
+	loc                 *Location
+	fsys                fs.FS
+	rawWSDL             []byte
+	pkg                 string
+	ignoreTLS           bool
+	catalog             *Catalog
+	cache               *downloadCache
+	offline             bool
+	fetchConfig         FetchConfig
+	fetcher             Fetcher
+	soapImport          string
+	templates           Templates
+	typeMap             TypeMap
+	common              *commonPackage
+	shared              map[*XSDSchema]bool
+	operationFilter     OperationFilter
+	filter              *operationFilter
+	reachableSymbols    map[symbol]bool
+	logger              *log.Logger
+	strict              bool
+	diagnostics         diagnostics
+	documents           *documentSet
+	naming              Naming
+	unexportTypes       bool
+	wsdl                *WSDL
+	schemas             *schemaGraph
+	resolvedWSDLImports map[string]bool
+	currentSchema       *XSDSchema
+	symbols             *symbolTable
+	rpcWrappers         []*Type
+	rpcTypes            map[string]string
+	substitutions       map[xml.Name][]substitution
+	derivations         map[xml.Name][]derivation
+}
+
+// Method setSchema sets (and returns) the schema whose types are being generated.
+func (g *GoWSDL) setSchema(schema *XSDSchema) *XSDSchema {
+	g.currentSchema = schema
+	return schema
+}
+
+// Method getSchema returns the schema whose types are being generated.
+func (g *GoWSDL) getSchema() *XSDSchema {
+	return g.currentSchema
+}
+
+// DefaultCacheDir returns the gowsdl directory of the user cache directory,
+// where downloaded documents may be cached with WithCacheDir.
+func DefaultCacheDir() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "gowsdl")
+	}
+	return filepath.Join(os.TempDir(), "gowsdl-cache")
 }
 
-// Method setNS sets (and returns) the currently active XML namespace.
-func (g *GoWSDL) setNS(ns string) string {
-	g.currentNamespace = ns
-	return ns
+// NewGoWSDL initializes WSDL generator.
+//
+// Deprecated: use New, which takes options.
+func NewGoWSDL(file, pkg string, ignoreTLS bool, exportAllTypes bool) (*GoWSDL, error) {
+	return New(file, WithPackage(pkg), WithInsecureSkipVerify(ignoreTLS), WithExportAllTypes(exportAllTypes))
 }
 
-// Method setNS returns the currently active XML namespace.
-func (g *GoWSDL) getNS() string {
-	return g.currentNamespace
+// NewGoWSDLFS initializes WSDL generator for the WSDL file within fsys, such
+// as an embed.FS. Relative references from the WSDL and its schemas resolve
+// within fsys.
+//
+// Deprecated: use New with WithFS.
+func NewGoWSDLFS(fsys fs.FS, file, pkg string, ignoreTLS bool, exportAllTypes bool) (*GoWSDL, error) {
+	return New(file, WithFS(fsys), WithPackage(pkg), WithInsecureSkipVerify(ignoreTLS), WithExportAllTypes(exportAllTypes))
 }
 
-var cacheDir = filepath.Join(os.TempDir(), "gowsdl-cache")
+// Code is the Go code generated from a WSDL.
+type Code struct {
+	// Header, Types and Operations make up the client.
+	Header     []byte
+	Types      []byte
+	Operations []byte
 
-func init() {
-	err := os.MkdirAll(cacheDir, 0700)
-	if err != nil {
-		log.Println("Create cache directory", "error", err)
-		os.Exit(1)
-	}
+	// ServerHeader, ServerWSDL and Server make up the server. ServerWSDL
+	// declares the WSDL the server serves.
+	ServerHeader []byte
+	ServerWSDL   []byte
+	Server       []byte
+
+	// positions maps the generated types to the position of their
+	// declaration.
+	positions map[string]Position
 }
 
-var timeout = time.Duration(30 * time.Second)
+// Client returns the formatted source of the client.
+func (c *Code) Client() ([]byte, error) {
+	return c.format(c.Header, c.Types, c.Operations)
+}
 
-func dialTimeout(network, addr string) (net.Conn, error) {
-	return net.DialTimeout(network, addr, timeout)
+// ServerSource returns the formatted source of the server.
+func (c *Code) ServerSource() ([]byte, error) {
+	return c.format(c.ServerHeader, c.ServerWSDL, c.Server)
 }
 
-func downloadFile(url string, ignoreTLS bool) ([]byte, error) {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: ignoreTLS,
-		},
-		Dial: dialTimeout,
+// format concatenates parts and formats them, without the imports they don't
+// use. When they don't parse, the unformatted source is returned along with a
+// *DiagnosticError pointing at the declaration of the type the syntax error is
+// in.
+func (c *Code) format(parts ...[]byte) ([]byte, error) {
+	data := bytes.Join(parts, nil)
+	source, err := format.Source(pruneImports(data))
+	if err == nil {
+		return source, nil
 	}
-	client := &http.Client{Transport: tr}
 
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, err
+	d := Diagnostic{Severity: SeverityError, Code: CodeFormat, Message: err.Error(), Err: err}
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		if name := declarationAt(data, list[0].Pos.Line); name != "" {
+			d.Pos = c.positions[name]
+			d.Message = fmt.Sprintf("generated %s doesn't compile: %v", name, err)
+		}
 	}
+	return data, &DiagnosticError{Diagnostics: []Diagnostic{d}}
+}
 
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Received response code %d", resp.StatusCode)
+// pruneImports removes the imports source doesn't use, such as the packages of
+// mapped types only used by another file. It returns source as is when it
+// doesn't parse, or uses all its imports.
+func pruneImports(source []byte) []byte {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", source, parser.ParseComments)
+	if err != nil {
+		return source
 	}
 
-	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	used := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	pruned := false
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		specs := gen.Specs[:0]
+		for _, spec := range gen.Specs {
+			imp := spec.(*ast.ImportSpec)
+			p, _ := strconv.Unquote(imp.Path.Value)
+			name := path.Base(p)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			if name == "_" || name == "." || used[name] {
+				specs = append(specs, spec)
+				continue
+			}
+			pruned = true
+		}
+		gen.Specs = specs
+	}
+	if !pruned {
+		return source
 	}
 
-	return data, nil
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return source
+	}
+	return buf.Bytes()
 }
 
-// NewGoWSDL initializes WSDL generator.
-func NewGoWSDL(file, pkg string, ignoreTLS bool, exportAllTypes bool) (*GoWSDL, error) {
-	file = strings.TrimSpace(file)
-	if file == "" {
-		return nil, errors.New("WSDL file is required to generate Go proxy")
-	}
+var declarationRegexp = regexp.MustCompile(`^\s*(?:type (\w+)|func \(\w+ \*?(\w+)\))`)
 
-	pkg = strings.TrimSpace(pkg)
-	if pkg == "" {
-		pkg = "myservice"
+// declarationAt returns the name of the type declared around line of source,
+// or of the receiver of the method declared there.
+func declarationAt(source []byte, line int) string {
+	lines := bytes.Split(source, []byte("\n"))
+	if line > len(lines) {
+		line = len(lines)
 	}
-	makePublicFn := func(id string) string { return id }
-	if exportAllTypes {
-		makePublicFn = makePublic
+	for i := line - 1; i >= 0; i-- {
+		if m := declarationRegexp.FindSubmatch(lines[i]); m != nil {
+			return string(m[1]) + string(m[2])
+		}
 	}
+	return ""
+}
 
-	r, err := ParseLocation(file)
-	if err != nil {
-		return nil, err
+// Start initiaties the code generation process: it builds the Model of the
+// WSDL and renders the Go client and server.
+//
+// Problems found along the way are reported by Diagnostics. When any of them
+// is an error, or a warning in strict mode, Start returns a *DiagnosticError
+// along with the code generated, which may be incomplete.
+func (g *GoWSDL) Start() (*Code, error) {
+	if err := g.load(); err != nil {
+		g.fail(CodeLoad, Position{}, err)
+		return nil, g.failure()
 	}
 
-	return &GoWSDL{
-		loc:          r,
-		pkg:          pkg,
-		ignoreTLS:    ignoreTLS,
-		makePublicFn: makePublicFn,
-	}, nil
+	gocode, err := renderGo(g.buildModel(), g.templates)
+	if err != nil {
+		g.failWith(CodeTemplate, err)
+	}
+	return gocode, g.failure()
 }
 
-// Start initiaties the code generation process by starting two goroutines: one
-// to generate types and another one to generate operations.
-func (g *GoWSDL) Start() (map[string][]byte, error) {
-	gocode := make(map[string][]byte)
+// renderGo renders the Go code of model by starting three goroutines: one to
+// generate types, another one to generate operations and the last one to
+// generate the server. Failures are returned as a *DiagnosticError, along with
+// the code rendered. The blocks of the templates are overridden by overrides.
+func renderGo(model *Model, overrides Templates) (*Code, error) {
+	if err := overrides.check(); err != nil {
+		return nil, templateError(err)
+	}
 
-	err := g.unmarshal()
-	if err != nil {
-		return nil, err
+	gocode := &Code{positions: make(map[string]Position)}
+	for _, t := range model.Types {
+		gocode.positions[t.Name] = t.Pos
 	}
 
-	// Process WSDL nodes
-	for _, schema := range g.wsdl.Types.Schemas {
-		newTraverser(schema, g.wsdl.Types.Schemas).traverse()
+	var (
+		mu     sync.Mutex
+		failed []Diagnostic
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, Diagnostic{Severity: SeverityError, Code: CodeTemplate, Message: err.Error(), Err: err})
 	}
 
 	var wg sync.WaitGroup
@@ -144,9 +270,9 @@ func (g *GoWSDL) Start() (map[string][]byte, error) {
 		defer wg.Done()
 		var err error
 
-		gocode["types"], err = g.genTypes()
+		gocode.Types, err = genTypes(model, overrides)
 		if err != nil {
-			log.Println("genTypes", "error", err)
+			fail(fmt.Errorf("generating types: %w", err))
 		}
 	}()
 
@@ -155,9 +281,9 @@ func (g *GoWSDL) Start() (map[string][]byte, error) {
 		defer wg.Done()
 		var err error
 
-		gocode["operations"], err = g.genOperations()
+		gocode.Operations, err = genOperations(model, overrides)
 		if err != nil {
-			log.Println(err)
+			fail(fmt.Errorf("generating operations: %w", err))
 		}
 	}()
 
@@ -166,52 +292,116 @@ func (g *GoWSDL) Start() (map[string][]byte, error) {
 		defer wg.Done()
 		var err error
 
-		gocode["server"], err = g.genServer()
+		gocode.Server, err = genServer(model, overrides)
 		if err != nil {
-			log.Println(err)
+			fail(fmt.Errorf("generating server: %w", err))
 		}
 	}()
 
 	wg.Wait()
 
-	gocode["header"], err = g.genHeader()
+	var err error
+	gocode.Header, err = genHeader(model, overrides)
 	if err != nil {
-		log.Println(err)
+		fail(fmt.Errorf("generating header: %w", err))
 	}
 
-	gocode["server_header"], err = g.genServerHeader()
+	gocode.ServerHeader, err = genServerHeader(model, overrides)
 	if err != nil {
-		log.Println(err)
+		fail(fmt.Errorf("generating server header: %w", err))
 	}
 
-	gocode["server_wsdl"] = []byte("var wsdl = `" + string(g.rawWSDL) + "`")
+	gocode.ServerWSDL = []byte("var wsdl = `" + model.WSDL + "`")
 
+	if len(failed) > 0 {
+		return gocode, &DiagnosticError{Diagnostics: failed}
+	}
 	return gocode, nil
 }
 
 func (g *GoWSDL) fetchFile(loc *Location) (data []byte, err error) {
-	if loc.f != "" {
-		log.Println("Reading", "file", loc.f)
+	if loc.isFS() {
+		g.logger.Println("Reading", "file", loc.p)
+		data, err = fs.ReadFile(loc.fsys, loc.p)
+	} else if loc.f != "" {
+		g.logger.Println("Reading", "file", loc.f)
 		data, err = ioutil.ReadFile(loc.f)
 	} else {
-		log.Println("Downloading", "file", loc.u.String())
-		data, err = downloadFile(loc.u.String(), g.ignoreTLS)
+		data, err = g.download(loc.u.String())
+	}
+	if err == nil {
+		g.documents.add(loc, data)
 	}
 	return
 }
 
+// download returns the document at url from the cache, downloading and
+// caching it when missing.
+func (g *GoWSDL) download(url string) ([]byte, error) {
+	if data, ok := g.cache.get(url); ok {
+		g.logger.Println("Reading", "cached file", url)
+		return data, nil
+	}
+	if g.offline && g.cache == nil {
+		return nil, fmt.Errorf("cannot download %s offline without a cache directory", url)
+	}
+	if g.offline {
+		return nil, fmt.Errorf("%s is not cached, cannot download it offline", url)
+	}
+
+	g.logger.Println("Downloading", "file", url)
+	data, err := g.fetcher.Fetch(url)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.cache.put(url, data); err != nil {
+		g.logger.Printf("[WARN] Unable to cache %s: %v", url, err)
+	}
+	return data, nil
+}
+
+// lookupCatalog returns the location the catalog maps ref to, ref being the
+// location of a document or a namespace.
+func (g *GoWSDL) lookupCatalog(ref string) (*Location, bool) {
+	loc, ok := g.catalog.resolve(ref)
+	if ok {
+		g.logger.Println("Resolving", ref, "through catalog to", loc)
+	}
+	return loc, ok
+}
+
+// locate returns the location of the document referenced by ref relative to
+// base, as mapped by the catalog.
+func (g *GoWSDL) locate(base *Location, ref string) (*Location, error) {
+	loc, err := base.Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+	if mapped, ok := g.lookupCatalog(loc.String()); ok {
+		loc = mapped
+	}
+	g.documents.reference(base, ref, loc)
+	return loc, nil
+}
+
 func (g *GoWSDL) unmarshal() error {
+	if loc, ok := g.lookupCatalog(g.loc.String()); ok {
+		g.loc = loc
+	}
+	g.documents = newDocumentSet()
+
 	data, err := g.fetchFile(g.loc)
 	if err != nil {
 		return err
 	}
 
 	g.wsdl = new(WSDL)
-	err = xml.Unmarshal(data, g.wsdl)
+	err = decodeSource(g.loc.String(), data, g.wsdl)
 	if err != nil {
-		return err
+		return fmt.Errorf("unable to parse WSDL %s: %w", g.loc, err)
 	}
 	g.rawWSDL = data
+	g.schemas = newSchemaGraph()
 
 	for _, schema := range g.wsdl.Types.Schemas {
 		err = g.resolveXSDExternals(schema, g.loc)
@@ -220,183 +410,114 @@ func (g *GoWSDL) unmarshal() error {
 		}
 	}
 
+	g.resolvedWSDLImports = map[string]bool{g.loc.String(): true}
+	err = g.resolveWSDLImports(g.wsdl, g.loc, []string{g.loc.String()})
+	if err != nil {
+		return err
+	}
+
+	g.checkImports()
 	return nil
 }
 
-func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, loc *Location) error {
-	download := func(base *Location, ref string) error {
-		location, err := base.Parse(ref)
-		if err != nil {
-			return err
-		}
-		schemaKey := location.String()
-		if g.resolvedXSDExternals[location.String()] {
-			return nil
+// resolveWSDLImports loads the documents imported by wsdl, which was read from
+// loc, and merges their definitions into g.wsdl. Imported documents are loaded
+// once, chain holds the locations of the documents importing wsdl to report
+// import cycles.
+func (g *GoWSDL) resolveWSDLImports(wsdl *WSDL, loc *Location, chain []string) error {
+imports:
+	for _, imp := range wsdl.Imports {
+		var location *Location
+		if imp.Location == "" {
+			var ok bool
+			if location, ok = g.lookupCatalog(imp.Namespace); !ok {
+				g.warnf(CodeWSDLImport, imp.Pos, "Don't know where to find WSDL for %s", imp.Namespace)
+				continue
+			}
+		} else {
+			var err error
+			if location, err = g.locate(loc, imp.Location); err != nil {
+				return err
+			}
 		}
-		if g.resolvedXSDExternals == nil {
-			g.resolvedXSDExternals = make(map[string]bool, maxRecursion)
+		key := location.String()
+		for _, l := range chain {
+			if l == key {
+				g.warnf(CodeImportCycle, imp.Pos, "wsdl:import cycle %s, ignoring import", strings.Join(extendChain(chain, key), " -> "))
+				continue imports
+			}
 		}
-		g.resolvedXSDExternals[schemaKey] = true
-
-		var data []byte
-		if data, err = g.fetchFile(location); err != nil {
-			return err
+		if g.resolvedWSDLImports[key] {
+			continue
 		}
+		g.resolvedWSDLImports[key] = true
 
-		newschema := new(XSDSchema)
-
-		err = xml.Unmarshal(data, newschema)
+		data, err := g.fetchFile(location)
 		if err != nil {
 			return err
 		}
 
-		if (len(newschema.Includes) > 0 || len(newschema.Imports) > 0) &&
-			maxRecursion > g.currentRecursionLevel {
-			g.currentRecursionLevel++
-
-			err = g.resolveXSDExternals(newschema, location)
-			if err != nil {
+		// wsdl:import may also refer to a schema document.
+		if isSchemaDocument(data) {
+			node, loaded := g.schemas.node(location, imp.Namespace)
+			if loaded {
+				continue
+			}
+			if err := g.parseSchemaDocument(node, data, imp.Namespace, false, chain); err != nil {
 				return err
 			}
+			continue
 		}
 
-		g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, newschema)
-
-		return nil
-	}
-
-	for _, impts := range schema.Imports {
-		// Download the file only if we have a hint in the form of schemaLocation.
-		if impts.SchemaLocation == "" {
-			log.Printf("[WARN] Don't know where to find XSD for %s", impts.Namespace)
-			continue
+		imported := new(WSDL)
+		if err := decodeSource(location.String(), data, imported); err != nil {
+			return err
 		}
+		g.mergeWSDL(imported)
 
-		if e := download(loc, impts.SchemaLocation); e != nil {
-			return e
+		for _, schema := range imported.Types.Schemas {
+			if err := g.resolveXSDExternals(schema, location); err != nil {
+				return err
+			}
 		}
-	}
 
-	for _, incl := range schema.Includes {
-		if e := download(loc, incl.SchemaLocation); e != nil {
-			return e
+		if err := g.resolveWSDLImports(imported, location, extendChain(chain, key)); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func (g *GoWSDL) genTypes() ([]byte, error) {
-	funcMap := template.FuncMap{
-		"toGoType":                 toGoType,
-		"stripns":                  stripns,
-		"replaceReservedWords":     replaceReservedWords,
-		"replaceAttrReservedWords": replaceAttrReservedWords,
-		"normalize":                normalize,
-		"makePublic":               g.makePublicFn,
-		"makeFieldPublic":          makePublic,
-		"comment":                  comment,
-		"removeNS":                 removeNS,
-		"goString":                 goString,
-		"findNameByType":           g.findNameByType,
-		"removePointerFromType":    removePointerFromType,
-		"setNS":                    g.setNS,
-		"getNS":                    g.getNS,
-	}
-
-	data := new(bytes.Buffer)
-	tmpl := template.Must(template.New("types").Funcs(funcMap).Parse(typesTmpl))
-	err := tmpl.Execute(data, g.wsdl.Types)
-	if err != nil {
-		return nil, err
-	}
-
-	return data.Bytes(), nil
-}
-
-func (g *GoWSDL) genOperations() ([]byte, error) {
-	funcMap := template.FuncMap{
-		"toGoType":             toGoType,
-		"stripns":              stripns,
-		"replaceReservedWords": replaceReservedWords,
-		"normalize":            normalize,
-		"makePublic":           g.makePublicFn,
-		"makePrivate":          makePrivate,
-		"findType":             g.findType,
-		"findSOAPAction":       g.findSOAPAction,
-		"findServiceAddress":   g.findServiceAddress,
-	}
-
-	data := new(bytes.Buffer)
-	tmpl := template.Must(template.New("operations").Funcs(funcMap).Parse(opsTmpl))
-	err := tmpl.Execute(data, g.wsdl.PortTypes)
-	if err != nil {
-		return nil, err
-	}
-
-	return data.Bytes(), nil
-}
-
-func (g *GoWSDL) genServer() ([]byte, error) {
-	funcMap := template.FuncMap{
-		"toGoType":             toGoType,
-		"stripns":              stripns,
-		"replaceReservedWords": replaceReservedWords,
-		"makePublic":           g.makePublicFn,
-		"findType":             g.findType,
-		"findSOAPAction":       g.findSOAPAction,
-		"findServiceAddress":   g.findServiceAddress,
-	}
-
-	data := new(bytes.Buffer)
-	tmpl := template.Must(template.New("server").Funcs(funcMap).Parse(serverTmpl))
-	err := tmpl.Execute(data, g.wsdl.PortTypes)
-	if err != nil {
-		return nil, err
-	}
-
-	return data.Bytes(), nil
-}
-
-func (g *GoWSDL) genHeader() ([]byte, error) {
-	funcMap := template.FuncMap{
-		"toGoType":             toGoType,
-		"stripns":              stripns,
-		"replaceReservedWords": replaceReservedWords,
-		"normalize":            normalize,
-		"makePublic":           g.makePublicFn,
-		"findType":             g.findType,
-		"comment":              comment,
-	}
-
-	data := new(bytes.Buffer)
-	tmpl := template.Must(template.New("header").Funcs(funcMap).Parse(headerTmpl))
-	err := tmpl.Execute(data, g.pkg)
-	if err != nil {
-		return nil, err
+// mergeWSDL adds the definitions of an imported document to g.wsdl. The QNames
+// of message parts are rewritten to the namespace declarations of g.wsdl.
+func (g *GoWSDL) mergeWSDL(w *WSDL) {
+	for _, msg := range w.Messages {
+		for _, part := range msg.Parts {
+			part.Element = requalify(parseQName(part.Element, w.Xmlns), g.wsdl.Xmlns)
+			part.Type = requalify(parseQName(part.Type, w.Xmlns), g.wsdl.Xmlns)
+		}
 	}
 
-	return data.Bytes(), nil
+	g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, w.Types.Schemas...)
+	g.wsdl.Messages = append(g.wsdl.Messages, w.Messages...)
+	g.wsdl.PortTypes = append(g.wsdl.PortTypes, w.PortTypes...)
+	g.wsdl.Binding = append(g.wsdl.Binding, w.Binding...)
+	g.wsdl.Service = append(g.wsdl.Service, w.Service...)
 }
 
-func (g *GoWSDL) genServerHeader() ([]byte, error) {
-	funcMap := template.FuncMap{
-		"toGoType":             toGoType,
-		"stripns":              stripns,
-		"replaceReservedWords": replaceReservedWords,
-		"makePublic":           g.makePublicFn,
-		"findType":             g.findType,
-		"comment":              comment,
-	}
-
-	data := new(bytes.Buffer)
-	tmpl := template.Must(template.New("server_header").Funcs(funcMap).Parse(serverHeaderTmpl))
-	err := tmpl.Execute(data, g.pkg)
-	if err != nil {
-		return nil, err
+// isSchemaDocument reports whether the root element of data is xs:schema.
+func isSchemaDocument(data []byte) bool {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Space == xmlschema11 && start.Name.Local == "schema"
+		}
 	}
-
-	return data.Bytes(), nil
 }
 
 var reservedWords = map[string]string{
@@ -540,26 +661,84 @@ func removeNS(xsdType string) string {
 	return r[0]
 }
 
-func toGoType(xsdType string, nillable bool) string {
-	// Handles name space, ie. xsd:string, xs:string
-	r := strings.Split(xsdType, ":")
+// toGoType returns the Go type generated for the XSD type named xsdType
+// within the schema being generated. Only built-in types are values, unless
+// nillable. An undeclared type is reported at pos, the position of the
+// declaration referencing it.
+func (g *GoWSDL) toGoType(xsdType string, nillable bool, pos Position) string {
+	if xsdType == "" {
+		return ""
+	}
+	return g.goType(typeSymbol, g.currentSchema.qname(xsdType), nillable, pos)
+}
 
-	t := r[0]
+// toGoElementType returns the Go type generated for the global element named
+// ref within the schema being generated. An undeclared element is reported at
+// pos.
+func (g *GoWSDL) toGoElementType(ref string, nillable bool, pos Position) string {
+	if ref == "" {
+		return ""
+	}
+	return g.goType(elementSymbol, g.currentSchema.qname(ref), nillable, pos)
+}
 
-	if len(r) == 2 {
-		t = r[1]
+// goType returns the Go type generated for the global component qname of
+// kind, reporting it at pos when it isn't declared.
+func (g *GoWSDL) goType(kind symbolKind, qname xml.Name, nillable bool, pos Position) string {
+	goName, builtin, ok := g.symbols.lookup(kind, qname)
+	if !ok {
+		if kind == elementSymbol {
+			g.warnf(CodeUnresolvedElement, pos, "element %s is not declared", qname.Local)
+		} else {
+			g.warnf(CodeUnresolvedType, pos, "type %s is not declared", qname.Local)
+		}
+		goName = g.typeName(qname.Local)
 	}
+	if builtin && !nillable {
+		return goName
+	}
+	return "*" + goName
+}
 
-	value := xsd2GoTypes[strings.ToLower(t)]
+// goName returns the name of the Go type generated for the global component
+// name of kind.
+func (g *GoWSDL) goName(kind symbolKind, name xml.Name) string {
+	if goName, ok := g.symbols.names[symbol{kind, name}]; ok {
+		return goName
+	}
+	return g.typeName(name.Local)
+}
 
-	if value != "" {
-		if nillable {
-			value = "*" + value
-		}
-		return value
+// typeName returns the name of the Go type of the schema type or element
+// name, unexported unless the types are exported.
+func (g *GoWSDL) typeName(name string) string {
+	if g.unexportTypes {
+		return unexport(g.naming.Type(name))
+	}
+	return g.naming.Type(name)
+}
+
+// elementNS returns the namespace of a local element or element reference of
+// the schema being generated.
+func (g *GoWSDL) elementNS(elm *XSDElement) string {
+	if elm.Ref != "" {
+		return g.currentSchema.qname(elm.Ref).Space
 	}
+	return elementNamespace(g.currentSchema, elm, false)
+}
 
-	return "*" + replaceReservedWords(makePublic(t))
+// attributeNS returns the namespace of a local attribute or attribute
+// reference of the schema being generated.
+func (g *GoWSDL) attributeNS(attr *XSDAttribute) string {
+	if attr.Ref != "" {
+		return g.currentSchema.qname(attr.Ref).Space
+	}
+	return attributeNamespace(g.currentSchema, attr)
+}
+
+// qname resolves a prefixed name used within the schema being generated.
+func (g *GoWSDL) qname(name string) xml.Name {
+	return g.currentSchema.qname(name)
 }
 
 func removePointerFromType(goType string) string {
@@ -574,6 +753,11 @@ func removePointerFromType(goType string) string {
 func (g *GoWSDL) findType(message string) string {
 	message = stripns(message)
 
+	// RPC style messages are wrapped in an element named after the operation.
+	if name, ok := g.rpcTypes[message]; ok {
+		return name
+	}
+
 	for _, msg := range g.wsdl.Messages {
 		if msg.Name != message {
 			continue
@@ -582,64 +766,126 @@ func (g *GoWSDL) findType(message string) string {
 		// Assumes document/literal wrapped WS-I
 		if len(msg.Parts) == 0 {
 			// Message does not have parts. This could be a Port
-			// with HTTP binding or SOAP 1.2 binding, which are not currently
-			// supported.
-			log.Printf("[WARN] %s message doesn't have any parts, ignoring message...", msg.Name)
+			// with HTTP binding, which is not currently supported.
+			g.warnf(CodeEmptyMessage, msg.Pos, "%s message doesn't have any parts, ignoring message...", msg.Name)
 			continue
 		}
 
-		part := msg.Parts[0]
-		if part.Type != "" {
-			return stripns(part.Type)
+		if goType, ok := g.partType(msg, msg.Parts[0]); ok {
+			return goType
 		}
+	}
+	return ""
+}
 
-		elRef := stripns(part.Element)
+// findMessage returns the message named name.
+func (g *GoWSDL) findMessage(name string) *WSDLMessage {
+	for _, msg := range g.wsdl.Messages {
+		if msg.Name == name {
+			return msg
+		}
+	}
+	return nil
+}
+
+// partType returns the Go type of part of msg, without pointer, and whether
+// it resolved.
+func (g *GoWSDL) partType(msg *WSDLMessage, part *WSDLPart) (string, bool) {
+	if part.Type != "" {
+		return removePointerFromType(g.goType(typeSymbol, parseQName(part.Type, g.wsdl.Xmlns), false, part.Pos)), true
+	}
+
+	schema, el := g.findElement(parseQName(part.Element, g.wsdl.Xmlns))
+	if el == nil {
+		g.warnf(CodeUnresolvedElement, part.Pos, "element %s of message %s is not declared", part.Element, msg.Name)
+		return "", false
+	}
+	if el.Type != "" {
+		return removePointerFromType(g.goType(typeSymbol, schema.qname(el.Type), false, el.Pos)), true
+	}
+	goName, _, _ := g.symbols.lookup(elementSymbol, xml.Name{Space: schema.TargetNamespace, Local: el.Name})
+	return goName, true
+}
 
-		for _, schema := range g.wsdl.Types.Schemas {
-			for _, el := range schema.Elements {
-				if strings.EqualFold(elRef, el.Name) {
-					if el.Type != "" {
-						return stripns(el.Type)
-					}
-					return el.Name
-				}
+// findElement returns the global element name along with its schema. Elements
+// are matched by local name when none is declared in the namespace of name.
+func (g *GoWSDL) findElement(name xml.Name) (*XSDSchema, *XSDElement) {
+	for _, schema := range g.wsdl.Types.Schemas {
+		if schema.TargetNamespace != name.Space {
+			continue
+		}
+		for _, el := range schema.Elements {
+			if el.Name == name.Local {
+				return schema, el
 			}
 		}
 	}
-	return ""
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, el := range schema.Elements {
+			if strings.EqualFold(name.Local, el.Name) {
+				return schema, el
+			}
+		}
+	}
+	return nil, nil
+}
+
+// findComplexType returns the global complex type name along with its schema.
+func (g *GoWSDL) findComplexType(name xml.Name) (*XSDSchema, *XSDComplexType) {
+	for _, schema := range g.wsdl.Types.Schemas {
+		if schema.TargetNamespace != name.Space {
+			continue
+		}
+		for _, ct := range schema.ComplexTypes {
+			if ct.Name == name.Local {
+				return schema, ct
+			}
+		}
+	}
+	return nil, nil
 }
 
-// Given a type, check if there's an Element with that type, and return its name.
-func (g *GoWSDL) findNameByType(name string) string {
-	return newTraverser(nil, g.wsdl.Types.Schemas).findNameByType(name)
+// findElementByType checks if there's an Element of the type name, and returns
+// its qualified name.
+func (g *GoWSDL) findElementByType(name xml.Name) xml.Name {
+	t := newTraverser(nil, g.wsdl.Types.Schemas)
+	t.symbols = g.symbols
+	return t.findNameByType(name)
 }
 
 // TODO(c4milo): Add support for namespaces instead of striping them out
 // TODO(c4milo): improve runtime complexity if performance turns out to be an issue.
 func (g *GoWSDL) findSOAPAction(operation, portType string) string {
+	binding, soapOp := g.findBindingOperation(operation, portType)
+	if soapOp == nil {
+		return ""
+	}
+	if binding.IsSOAP12() {
+		return soapOp.SOAP12Operation.SOAPAction
+	}
+	return soapOp.SOAPOperation.SOAPAction
+}
+
+// findSOAPVersion returns the SOAP protocol version ("1.1" or "1.2") used to
+// invoke the operations of portType. SOAP 1.1 is preferred whenever the port
+// type has a binding for it, so WSDLs offering both keep generating 1.1 clients.
+func (g *GoWSDL) findSOAPVersion(portType string) string {
+	version := ""
 	for _, binding := range g.wsdl.Binding {
 		if strings.ToUpper(stripns(binding.Type)) != strings.ToUpper(portType) {
 			continue
 		}
 
-		for _, soapOp := range binding.Operations {
-			if soapOp.Name == operation {
-				return soapOp.SOAPOperation.SOAPAction
-			}
+		if !binding.IsSOAP12() {
+			return "1.1"
 		}
+		version = "1.2"
 	}
-	return ""
-}
-
-func (g *GoWSDL) findServiceAddress(name string) string {
-	for _, service := range g.wsdl.Service {
-		for _, port := range service.Ports {
-			if port.Name == name {
-				return port.SOAPAddress.Location
-			}
-		}
+	if version == "" {
+		return "1.1"
 	}
-	return ""
+	return version
 }
 
 // TODO(c4milo): Add namespace support instead of stripping it
@@ -15,11 +15,15 @@ import (
 	"go/token"
 	"io/ioutil"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestElementGenerationDoesntCommentOutStructProperty(t *testing.T) {
@@ -33,9 +37,9 @@ func TestElementGenerationDoesntCommentOutStructProperty(t *testing.T) {
 		t.Error(err)
 	}
 
-	if strings.Contains(string(resp["types"]), "// this is a comment  GetInfoResult string `xml:\"GetInfoResult,omitempty\"`") {
+	if strings.Contains(string(resp.Types), "// this is a comment  GetInfoResult string `xml:\"GetInfoResult,omitempty\"`") {
 		t.Error("Type comment should not comment out struct type property")
-		t.Error(string(resp["types"]))
+		t.Error(string(resp.Types))
 	}
 }
 
@@ -57,7 +61,7 @@ func TestComplexTypeWithInlineSimpleType(t *testing.T) {
 	expected := `type GetInfo struct {
 	XMLName	xml.Name	` + "`" + `xml:"http://www.mnb.hu/webservices/ GetInfo"` + "`" + `
 
-	Id	string	` + "`" + `xml:"Id,omitempty" json:"Id,omitempty"` + "`" + `
+	ID	string	` + "`" + `xml:"http://www.mnb.hu/webservices/ Id,omitempty" json:"Id,omitempty"` + "`" + `
 }`
 	if actual != expected {
 		t.Error("got " + actual + " want " + expected)
@@ -76,7 +80,7 @@ func TestAttributeRef(t *testing.T) {
 	}
 	actual, err := getTypeDeclaration(resp, "ResponseStatus")
 	if err != nil {
-		fmt.Println(string(resp["types"]))
+		fmt.Println(string(resp.Types))
 		t.Fatal(err)
 	}
 
@@ -84,8 +88,8 @@ func TestAttributeRef(t *testing.T) {
 	Status	[]struct {
 		Value	string  ` + "`" + `xml:",chardata" json:"-,"` + "`" + `
 
-		Code	string	` + "`" + `xml:"http://www.mnb.hu/webservices/ code,attr,omitempty" json:"code,omitempty"` + "`" + `
-	}	` + "`" + `xml:"status,omitempty" json:"status,omitempty"` + "`" + `
+		Code	string	` + "`" + `xml:"code,attr,omitempty" json:"code,omitempty"` + "`" + `
+	}	` + "`" + `xml:"http://www.mnb.hu/webservices/ status,omitempty" json:"status,omitempty"` + "`" + `
 
 	ResponseCode	string	` + "`" + `xml:"http://www.mnb.hu/webservices/ responseCode,attr,omitempty" json:"responseCode,omitempty"` + "`" + `
 }`
@@ -110,7 +114,7 @@ func TestElementWithLocalSimpleType(t *testing.T) {
 	// Type declaration
 	actual, err := getTypeDeclaration(resp, "ElementWithLocalSimpleType")
 	if err != nil {
-		fmt.Println(string(resp["types"]))
+		fmt.Println(string(resp.Types))
 		t.Fatal(err)
 	}
 
@@ -123,7 +127,7 @@ func TestElementWithLocalSimpleType(t *testing.T) {
 	// Const declaration of first enum value
 	actual, err = getTypeDeclaration(resp, "ElementWithLocalSimpleTypeEnum1")
 	if err != nil {
-		fmt.Println(string(resp["types"]))
+		fmt.Println(string(resp.Types))
 		t.Fatal(err)
 	}
 
@@ -136,7 +140,7 @@ func TestElementWithLocalSimpleType(t *testing.T) {
 	// Const declaration of second enum value
 	actual, err = getTypeDeclaration(resp, "ElementWithLocalSimpleTypeEnum2")
 	if err != nil {
-		fmt.Println(string(resp["types"]))
+		fmt.Println(string(resp.Types))
 		t.Fatal(err)
 	}
 
@@ -161,7 +165,7 @@ func TestDateTimeType(t *testing.T) {
 	// Type declaration
 	actual, err := getTypeDeclaration(resp, "StartDate")
 	if err != nil {
-		fmt.Println(string(resp["types"]))
+		fmt.Println(string(resp.Types))
 		t.Fatal(err)
 	}
 
@@ -174,7 +178,7 @@ func TestDateTimeType(t *testing.T) {
 	// Method declaration MarshalXML
 	actual, err = getFuncDeclaration(resp, "MarshalXML", "StartDate")
 	if err != nil {
-		fmt.Println(string(resp["types"]))
+		fmt.Println(string(resp.Types))
 		t.Fatal(err)
 	}
 
@@ -189,7 +193,7 @@ func TestDateTimeType(t *testing.T) {
 	// Method declaration UnmarshalXML
 	actual, err = getFuncDeclaration(resp, "UnmarshalXML", "StartDate")
 	if err != nil {
-		fmt.Println(string(resp["types"]))
+		fmt.Println(string(resp.Types))
 		t.Fatal(err)
 	}
 
@@ -221,10 +225,9 @@ func TestVboxGeneratesWithoutSyntaxErrors(t *testing.T) {
 		}
 
 		data := new(bytes.Buffer)
-		data.Write(resp["header"])
-		data.Write(resp["types"])
-		data.Write(resp["operations"])
-		data.Write(resp["soap"])
+		data.Write(resp.Header)
+		data.Write(resp.Types)
+		data.Write(resp.Operations)
 
 		_, err = format.Source(data.Bytes())
 		if err != nil {
@@ -246,7 +249,7 @@ func TestEnumerationsGeneratedCorrectly(t *testing.T) {
 			t.Error(err)
 		}
 		re := regexp.MustCompile(varName + " " + typeName + " = \"([^\"]*)\"")
-		matches := re.FindStringSubmatch(string(resp["types"]))
+		matches := re.FindStringSubmatch(string(resp.Types))
 
 		if len(matches) != 2 {
 			t.Errorf("No match or too many matches found for %s", varName)
@@ -284,6 +287,630 @@ func TestComplexTypeGeneratedCorrectly(t *testing.T) {
 	}
 }
 
+func TestSOAP12Binding(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/soap12.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops := string(resp.Operations)
+	if !strings.Contains(ops, `"http://example.com/weather/GetForecast"`) {
+		t.Error("SOAP action of the soap12 binding should be used")
+		t.Error(ops)
+	}
+	if !strings.Contains(ops, "service.client.CallContextWithVersion(ctx, soap.SOAP12, ") || strings.Contains(ops, "service.client.CallContext(") {
+		t.Error("operations of a SOAP 1.2 port type should be called with SOAP 1.2")
+		t.Error(ops)
+	}
+	m, err := g.Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Services[0].Address; got != "http://example.com/weather.asmx" {
+		t.Errorf("got service address %q", got)
+	}
+
+	// A port type bound with both SOAP 1.1 and 1.2 keeps using SOAP 1.1.
+	g, err = NewGoWSDL("fixtures/mnb-exchange.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := g.findSOAPVersion("mNBArfolyamServiceSoap"); v != "1.1" {
+		t.Errorf("got SOAP version %s want 1.1", v)
+	}
+}
+
+func TestRPCStyle(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/rpc.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "Add")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `type Add struct {
+	XMLName	xml.Name	` + "`" + `xml:"urn:calculator add"` + "`" + `
+
+	A	int32	` + "`" + `xml:"a,omitempty" json:"a,omitempty"` + "`" + `
+
+	B	int32	` + "`" + `xml:"b,omitempty" json:"b,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "MarshalXML", "Describe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `func (r Describe) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return soap.EncodeRPC(e, xml.Name{Space: "urn:machines", Local: "describe"}, "http://schemas.xmlsoap.org/soap/encoding/",
+		soap.RPCPart{Name: "machine", Type: xml.Name{Space: "urn:calculator", Local: "Machine"}, Value: r.Machine},
+	)
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getTypeDeclaration(resp, "ArrayOfString")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != "type ArrayOfString []string" {
+		t.Error("got " + actual + " want SOAP encoded array as slice")
+	}
+
+	ops, err := format.Source(resp.Operations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(ops), "Describe(request *Describe) (*DescribeResponse, error)") {
+		t.Error("RPC operation should use its synthesized wrapper types")
+		t.Error(string(ops))
+	}
+}
+
+func TestNamespaceCollisions(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/namespaces.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "PlaceOrder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `type PlaceOrder struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/orders/ PlaceOrder"` + "`" + `
+
+	BillTo	*OrdersAddress	` + "`" + `xml:"BillTo,omitempty" json:"BillTo,omitempty"` + "`" + `
+
+	ShipTo	*Address	` + "`" + `xml:"ShipTo,omitempty" json:"ShipTo,omitempty"` + "`" + `
+
+	Tracking	*Tracking	` + "`" + `xml:"http://example.com/shipping/v2 Tracking,omitempty" json:"Tracking,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getTypeDeclaration(resp, "Address")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{
+		`xml:"http://example.com/shipping/v2 Carrier,omitempty"`,
+		`xml:"http://example.com/shipping/v2 priority,attr,omitempty"`,
+	} {
+		if !strings.Contains(actual, tag) {
+			t.Errorf("Address should be tagged with %s, got \n%s", tag, actual)
+		}
+	}
+
+	actual, err = getTypeDeclaration(resp, "OrdersAddress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(actual, `xml:"country,attr,omitempty"`) {
+		t.Error("unqualified attributes should not be namespaced, got \n" + actual)
+	}
+
+	ops, err := format.Source(resp.Operations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(ops), "PlaceOrder(request *PlaceOrder) (*OrdersAddress, error)") {
+		t.Error("operations should use the renamed types")
+		t.Error(string(ops))
+	}
+}
+
+func TestWSDLImports(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/wsdlimport/service.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "GetStock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `type GetStock struct {
+	XMLName	xml.Name	` + "`" + `xml:"urn:example:inventory:types GetStock"` + "`" + `
+
+	Sku	string	` + "`" + `xml:"urn:example:inventory:types Sku,omitempty" json:"Sku,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	ops, err := format.Source(resp.Operations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"GetStock(request *GetStock) (*GetStockResponse, error)",
+		`"urn:example:inventory:GetStock"`,
+	} {
+		if !strings.Contains(string(ops), want) {
+			t.Errorf("operations of imported documents should contain %s", want)
+		}
+	}
+	if t.Failed() {
+		t.Log(string(ops))
+	}
+}
+
+func TestWSDLImportCycle(t *testing.T) {
+	g, err := New("fixtures/wsdlimport/cycle/a.wsdl", WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := g.Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a.wsdl is read once, and the import back to it is reported once.
+	var cycles []string
+	for _, d := range g.Diagnostics() {
+		if d.Code == CodeImportCycle {
+			cycles = append(cycles, d.Message)
+		}
+	}
+	if len(cycles) != 1 || !strings.Contains(cycles[0], "a.wsdl -> ") || !strings.HasSuffix(cycles[0], "a.wsdl, ignoring import") {
+		t.Errorf("got cycles %q, want a.wsdl -> b.wsdl -> a.wsdl", cycles)
+	}
+	if len(g.wsdl.Service) != 1 {
+		t.Errorf("got %d services, want a.wsdl merged once", len(g.wsdl.Service))
+	}
+	if m.Type("Echo") == nil {
+		t.Error("the types of b.wsdl are missing")
+	}
+}
+
+func TestSchemaGraph(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/schemagraph/cycle.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// common.xsd is included into both namespaces.
+	for name, field := range map[string]string{
+		"Order":    "Status\t*AStatus",
+		"Customer": "Status\t*Status",
+	} {
+		actual, err := getTypeDeclaration(resp, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(actual, field) {
+			t.Errorf("%s should contain field %q, got \n%s", name, field, actual)
+		}
+	}
+}
+
+func TestSchemaGraphDeepNesting(t *testing.T) {
+	const depth = 30
+
+	dir := t.TempDir()
+	wsdl := `<wsdl:definitions xmlns:wsdl="http://schemas.xmlsoap.org/wsdl/" xmlns:s="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:level">
+  <wsdl:types>
+    <s:schema targetNamespace="urn:level"><s:include schemaLocation="level1.xsd"/></s:schema>
+  </wsdl:types>
+</wsdl:definitions>`
+	if err := ioutil.WriteFile(filepath.Join(dir, "service.wsdl"), []byte(wsdl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= depth; i++ {
+		include := ""
+		if i < depth {
+			include = fmt.Sprintf(`<s:include schemaLocation="level%d.xsd"/>`, i+1)
+		}
+		xsd := fmt.Sprintf(`<s:schema xmlns:s="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:level">%s<s:simpleType name="Level%d"><s:restriction base="s:string"/></s:simpleType></s:schema>`, include, i)
+		if err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("level%d.xsd", i)), []byte(xsd), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g, err := NewGoWSDL(filepath.Join(dir, "service.wsdl"), "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := fmt.Sprintf("Level%d", depth)
+	if _, err := getTypeDeclaration(resp, name); err != nil {
+		t.Errorf("schemas nested %d levels deep should be resolved: %v", depth, err)
+	}
+}
+
+func TestSchemaGraphUnreachable(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/schemagraph/unreachable.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = g.Start()
+	if err == nil {
+		t.Fatal("a missing schema should fail the generation")
+	}
+	for _, want := range []string{"missing.xsd", "unreachable.wsdl"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error should mention %s, got %v", want, err)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	// The schemas of service.wsdl are only available through the catalogs,
+	// schemas.example.invalid doesn't resolve.
+	for _, file := range []string{"fixtures/catalog/catalog.xml", "fixtures/catalog/catalog.json"} {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			catalog, err := LoadCatalog(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			g, err := New("fixtures/catalog/service.wsdl", WithCatalog(catalog))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := g.Start()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range []string{"Address", "Country", "Weight"} {
+				if _, err := getTypeDeclaration(resp, name); err != nil {
+					t.Errorf("type %s should be generated from the vendored schemas: %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestFS(t *testing.T) {
+	g, err := New("wsdlimport/service.wsdl", WithFS(os.DirFS("fixtures")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getTypeDeclaration(resp, "GetStock"); err != nil {
+		t.Error(err)
+	}
+
+	fsys := fstest.MapFS{
+		"service.wsdl": {Data: []byte(`<wsdl:definitions xmlns:wsdl="http://schemas.xmlsoap.org/wsdl/" xmlns:s="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:example:service">
+  <wsdl:types>
+    <s:schema targetNamespace="urn:example:service"><s:include schemaLocation="xsd/types.xsd"/></s:schema>
+  </wsdl:types>
+</wsdl:definitions>`)},
+		"xsd/types.xsd": {Data: []byte(`<s:schema xmlns:s="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:example:service">
+  <s:simpleType name="Sku"><s:restriction base="s:string"/></s:simpleType>
+</s:schema>`)},
+	}
+	g, err = New("service.wsdl", WithFS(fsys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getTypeDeclaration(resp, "Sku"); err != nil {
+		t.Error(err)
+	}
+}
+
+// newDocumentServer serves a WSDL at /Service.svc?wsdl importing another WSDL
+// and a schema, the way WCF services do.
+func newDocumentServer(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var doc string
+		switch r.URL.RawQuery {
+		case "wsdl":
+			doc = `<wsdl:definitions xmlns:wsdl="http://schemas.xmlsoap.org/wsdl/" targetNamespace="urn:example:service">
+  <wsdl:import namespace="urn:example:interface" location="` + server.URL + `/Service.svc?wsdl=wsdl0&amp;lang=en" />
+</wsdl:definitions>`
+		case "wsdl=wsdl0&lang=en":
+			doc = `<wsdl:definitions xmlns:wsdl="http://schemas.xmlsoap.org/wsdl/" xmlns:s="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:example:interface">
+  <wsdl:types>
+    <s:schema targetNamespace="urn:example:interface">
+      <s:import namespace="urn:example:types" schemaLocation='Service.svc?xsd=xsd0' />
+    </s:schema>
+  </wsdl:types>
+</wsdl:definitions>`
+		case "xsd=xsd0":
+			doc = `<s:schema xmlns:s="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:example:types">
+  <s:simpleType name="Sku"><s:restriction base="s:string"/></s:simpleType>
+</s:schema>`
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, doc)
+	}))
+	return server
+}
+
+func TestOffline(t *testing.T) {
+	server := newDocumentServer(t)
+	cache := t.TempDir()
+
+	generate := func(offline bool) error {
+		g, err := New(server.URL+"/Service.svc?wsdl", WithCacheDir(cache), WithOffline(offline))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := g.Start()
+		if err != nil {
+			return err
+		}
+		if _, err := getTypeDeclaration(resp, "Sku"); err != nil {
+			t.Error(err)
+		}
+		return nil
+	}
+
+	if err := generate(true); err == nil {
+		t.Error("offline generation should fail before the documents are cached")
+	}
+	if err := generate(false); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+	if err := generate(true); err != nil {
+		t.Errorf("offline generation should read the cached documents: %v", err)
+	}
+
+	// Nothing is cached by default.
+	g, err := New(server.URL+"/Service.svc?wsdl", WithOffline(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Start(); err == nil || !strings.Contains(err.Error(), "without a cache directory") {
+		t.Errorf("got %v, want offline generation to require a cache directory", err)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	server := newDocumentServer(t)
+	defer server.Close()
+
+	g, err := New(server.URL+"/Service.svc?wsdl", WithCacheDir(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	wsdl, err := g.Snapshot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, "Service.svc_wsdl.wsdl"); wsdl != expected {
+		t.Errorf("got %s wanted %s", wsdl, expected)
+	}
+
+	for file, ref := range map[string]string{
+		"Service.svc_wsdl.wsdl":               `location="Service.svc_wsdl_wsdl0_lang_en.wsdl"`,
+		"Service.svc_wsdl_wsdl0_lang_en.wsdl": `schemaLocation="Service.svc_xsd_xsd0.xsd"`,
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), ref) {
+			t.Errorf("%s should reference its copy with %s, got \n%s", file, ref, data)
+		}
+	}
+
+	server.Close()
+	g, err = New(wsdl, WithOffline(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getTypeDeclaration(resp, "Sku"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	var logs bytes.Buffer
+	g, err := New("fixtures/test.wsdl",
+		WithPackage("inventory"),
+		WithSOAPImport("example.com/fork/soapv2"),
+		WithExportAllTypes(false),
+		WithLogger(log.New(&logs, "", 0)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := resp.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The runtime is imported as soap whatever the name of its package.
+	for _, want := range []string{"package inventory", `soap "example.com/fork/soapv2"`} {
+		if !strings.Contains(string(client), want) {
+			t.Errorf("client should contain %s", want)
+		}
+	}
+
+	server, err := resp.ServerSource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(server), "var wsdl = `") {
+		t.Error("server should embed the WSDL")
+	}
+
+	if !strings.Contains(logs.String(), "Reading file") {
+		t.Errorf("progress should be logged to the logger, got %q", logs.String())
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	// Messages of the HTTP GET and POST bindings don't have parts.
+	g, err := New("fixtures/ferry.wsdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Start(); err != nil {
+		t.Fatalf("warnings should not fail the generation: %v", err)
+	}
+
+	var warning *Diagnostic
+	for _, d := range g.Diagnostics() {
+		if d.Code == CodeEmptyMessage {
+			warning = &d
+			break
+		}
+	}
+	if warning == nil {
+		t.Fatalf("messages without parts should be reported, got %v", g.Diagnostics())
+	}
+	if warning.Severity != SeverityWarning || !strings.HasSuffix(warning.Pos.File, "ferry.wsdl") {
+		t.Errorf("got %v", warning)
+	}
+
+	g, err = New("fixtures/ferry.wsdl", WithStrict(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.Start()
+	var derr *DiagnosticError
+	if !errors.As(err, &derr) {
+		t.Fatalf("warnings should fail the generation in strict mode, got %v", err)
+	}
+	if derr.Diagnostics[0].Code != CodeEmptyMessage {
+		t.Errorf("got %v", derr.Diagnostics)
+	}
+
+	// Dangling references are reported at the declaration holding them.
+	g, err = New("fixtures/workday-time-min.wsdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	warning = nil
+	for _, d := range g.Diagnostics() {
+		if d.Code == CodeUnresolvedType {
+			warning = &d
+			break
+		}
+	}
+	if warning == nil || warning.Pos.Line != 12 || !strings.HasSuffix(warning.Pos.File, "workday-time-min.wsdl") {
+		t.Errorf("got %v, want the undeclared type of the attribute on line 12", warning)
+	}
+
+	g, err = New("fixtures/schemagraph/unreachable.wsdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.Start()
+	if !errors.As(err, &derr) || derr.Diagnostics[0].Code != CodeLoad || derr.Diagnostics[0].Severity != SeverityError {
+		t.Errorf("missing schemas should be reported as load errors, got %v", err)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("error should wrap the cause, got %v", err)
+	}
+}
+
+func TestFormatErrorPosition(t *testing.T) {
+	g, err := New("fixtures/test.wsdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Break the generated GetInfo type.
+	resp.Types = bytes.Replace(resp.Types, []byte("type GetInfo struct {"), []byte("type GetInfo struct {{"), 1)
+	_, err = resp.Client()
+	var derr *DiagnosticError
+	if !errors.As(err, &derr) {
+		t.Fatalf("got %v, wanted a *DiagnosticError", err)
+	}
+	d := derr.Diagnostics[0]
+	if d.Code != CodeFormat || !strings.HasSuffix(d.Pos.File, "test.wsdl") || d.Pos.Line == 0 {
+		t.Errorf("syntax errors should point at the declaration of GetInfo, got %v", d)
+	}
+}
+
 func TestEPCISWSDL(t *testing.T) {
 	log.SetFlags(0)
 	log.SetOutput(os.Stdout)
@@ -298,10 +925,9 @@ func TestEPCISWSDL(t *testing.T) {
 		t.Fatal(err)
 	}
 	data := new(bytes.Buffer)
-	data.Write(resp["header"])
-	data.Write(resp["types"])
-	data.Write(resp["operations"])
-	data.Write(resp["soap"])
+	data.Write(resp.Header)
+	data.Write(resp.Types)
+	data.Write(resp.Operations)
 
 	// go fmt the generated code
 	source, err := format.Source(data.Bytes())
@@ -321,8 +947,8 @@ func TestEPCISWSDL(t *testing.T) {
 	}
 }
 
-func getTypeDeclaration(resp map[string][]byte, name string) (string, error) {
-	source, err := format.Source([]byte(string(resp["header"]) + string(resp["types"])))
+func getTypeDeclaration(resp *Code, name string) (string, error) {
+	source, err := format.Source([]byte(string(resp.Header) + string(resp.Types)))
 	if err != nil {
 		return "", err
 	}
@@ -374,8 +1000,8 @@ func findFuncDecl(f *ast.File, name string, recv string) *ast.Decl {
 	return nil
 }
 
-func getFuncDeclaration(resp map[string][]byte, name string, recv string) (string, error) {
-	source, err := format.Source([]byte(string(resp["header"]) + string(resp["types"])))
+func getFuncDeclaration(resp *Code, name string, recv string) (string, error) {
+	source, err := format.Source([]byte(string(resp.Header) + string(resp.Types)))
 	if err != nil {
 		return "", err
 	}
@@ -396,3 +1022,90 @@ func getFuncDeclaration(resp map[string][]byte, name string, recv string) (strin
 	}
 	return buf.String(), nil
 }
+
+// petsMain round trips the derived types of the client of pets.wsdl.
+const petsMain = `package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+)
+
+func main() {
+	var resp AdoptResponse
+	data := ` + "`" + `<AdoptResponse xmlns="urn:example:pets"><Puppy weeks="2"><Name>Rex</Name><Breed>Lab</Breed></Puppy></AdoptResponse>` + "`" + `
+	if err := xml.Unmarshal([]byte(data), &resp); err != nil {
+		fail(err)
+	}
+	if p := resp.Puppy; p == nil || p.Weeks != 2 || p.Dog == nil || p.Breed != "Lab" || p.Pet == nil || p.Name != "Rex" {
+		fail(fmt.Errorf("got puppy %+v", p))
+	}
+
+	adopt := Adopt{
+		Owner:    &Owner{Name: "Ann"},
+		Pet:      []*PetValue{{Value: &Cat{Pet: &Pet{Name: "Tom"}, Indoor: true}}},
+		Favorite: &DogValue2{Value: resp.Puppy},
+	}
+	data2, err := xml.Marshal(adopt)
+	if err != nil {
+		fail(err)
+	}
+	var back Adopt
+	if err := xml.Unmarshal(data2, &back); err != nil {
+		fail(err)
+	}
+	if cat, ok := back.Pet[0].Value.(*Cat); !ok || !cat.Indoor || cat.Name != "Tom" {
+		fail(fmt.Errorf("got pet %+v in %s", back.Pet[0].Value, data2))
+	}
+	if puppy, ok := back.Favorite.Value.(*Puppy); !ok || puppy.Weeks != 2 || puppy.Breed != "Lab" {
+		fail(fmt.Errorf("got favorite %+v in %s", back.Favorite.Value, data2))
+	}
+}
+
+func fail(err error) {
+	fmt.Println(err)
+	os.Exit(1)
+}
+`
+
+func TestDerivedTypesRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the generated code")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+
+	g, err := New("fixtures/derivation/pets.wsdl", WithPackage("main"), WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := code.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The directory is within the module for the SOAP runtime to resolve,
+	// and ignored by the go tool as it starts with an underscore.
+	dir, err := ioutil.TempDir(".", "_pets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "pets.go"), client, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(petsMain), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err := exec.Command(goTool, "run", "./"+filepath.Base(dir)).CombinedOutput(); err != nil {
+		t.Errorf("round trip failed: %v\n%s", err, out)
+	}
+}
@@ -4,21 +4,27 @@
 
 package gowsdl
 
-var headerTmpl = `
+// headerBlocks define the package clause and imports of every file of the
+// client, and the header of its main file declaring the helper types too.
+var headerBlocks = `
+{{define "FileHeader"}}
 // Code generated by gowsdl DO NOT EDIT.
 
-package {{.}}
+package {{.Package}}
 
 import (
 	"context"
 	"encoding/xml"
 	"time"
-	"github.com/hooklift/gowsdl/soap"
+	soap "{{.SOAPImport}}"
 
-	{{/*range .Imports*/}}
-		{{/*.*/}}
-	{{/*end*/}}
+	{{range .Imports}}{{with .Name}}{{.}} {{end}}"{{.Path}}"
+	{{end}}
 )
+{{end}}
+
+{{define "Header"}}
+{{template "FileHeader" .}}
 
 // against "unused imports"
 var _ time.Time
@@ -32,4 +38,9 @@ type AnyURI string
 
 type NCName string
 
+{{end}}
 `
+
+var headerTmpl = headerBlocks + `{{template "Header" .}}`
+
+var fileHeaderTmpl = headerBlocks + `{{template "FileHeader" .}}`
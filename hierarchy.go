@@ -0,0 +1,108 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import "encoding/xml"
+
+// derivation is a complex type derived by extension from another one.
+type derivation struct {
+	name xml.Name
+	// base is the type name extends.
+	base xml.Name
+}
+
+// collectDerivations returns the complex types of schemas derived by extension
+// from other complex types, keyed by base type, in declaration order. Types
+// derived from a derived type are derived from its base too. Bases generated
+// as something else than a struct, such as SOAP arrays, are left out.
+func collectDerivations(schemas []*XSDSchema) map[xml.Name][]derivation {
+	types := make(map[xml.Name]*XSDComplexType)
+	bases := make(map[xml.Name][]xml.Name)
+	var names []xml.Name
+	for _, schema := range schemas {
+		for _, ct := range schema.ComplexTypes {
+			name := xml.Name{Space: schema.TargetNamespace, Local: ct.Name}
+			if _, ok := types[name]; ok {
+				continue
+			}
+			types[name] = ct
+			names = append(names, name)
+			if base := ct.ComplexContent.Extension.Base; base != "" {
+				base := schema.qname(base)
+				bases[base] = append(bases[base], name)
+			}
+		}
+	}
+
+	derivations := make(map[xml.Name][]derivation)
+	for _, name := range names {
+		ct := types[name]
+		if ct.SimpleContent.Extension.Base != "" || soapArrayItemType(ct) != "" {
+			continue
+		}
+
+		var derived []derivation
+		seen := map[xml.Name]bool{name: true}
+		var walk func(base xml.Name)
+		walk = func(base xml.Name) {
+			for _, d := range bases[base] {
+				if seen[d] {
+					continue
+				}
+				seen[d] = true
+				derived = append(derived, derivation{name: d, base: base})
+				walk(d)
+			}
+		}
+		walk(name)
+		if len(derived) > 0 {
+			derivations[name] = derived
+		}
+	}
+	return derivations
+}
+
+// subtypes returns the types derived from the complex type base whose Go
+// types are declared in the package of its struct, and thus embed it. Types
+// mapped to other Go types are left out, and so are the types derived from
+// them.
+func (g *GoWSDL) subtypes(base xml.Name) []*Subtype {
+	baseType, builtin, ok := g.symbols.lookup(typeSymbol, base)
+	if !ok || builtin {
+		return nil
+	}
+
+	var subtypes []*Subtype
+	embedding := map[xml.Name]bool{base: true}
+	for _, d := range g.derivations[base] {
+		goType, builtin, ok := g.symbols.lookup(typeSymbol, d.name)
+		if !ok || builtin || !embedding[d.base] || packageQualifier(goType) != packageQualifier(baseType) {
+			continue
+		}
+		embedding[d.name] = true
+		subtypes = append(subtypes, &Subtype{Type: d.name, GoType: unqualify(goType)})
+	}
+	return subtypes
+}
+
+// claimHierarchies names the declarations generated beside the complex types
+// of schemas other types derive from, after the components of the schemas so
+// the latter keep their names: the interface named after the type with the Any
+// prefix, the struct holding any of them with the Value suffix, and their
+// soap.TypeHierarchy.
+func (g *GoWSDL) claimHierarchies(schemas []*XSDSchema) {
+	for _, schema := range schemas {
+		for _, ct := range schema.ComplexTypes {
+			base := xml.Name{Space: schema.TargetNamespace, Local: ct.Name}
+			if len(g.subtypes(base)) == 0 {
+				continue
+			}
+			name := g.symbols.names[symbol{typeSymbol, base}]
+			g.symbols.claim(symbol{anySymbol, base}, "Any"+name)
+			g.symbols.claim(symbol{valueSymbol, base}, name+"Value")
+			g.symbols.claim(symbol{hierarchySymbol, base}, makePrivate(name)+"Hierarchy")
+		}
+	}
+}
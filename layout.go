@@ -0,0 +1,155 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Layout is how the Go client is laid out in files.
+type Layout string
+
+const (
+	// SingleFile generates the client in one file, the default.
+	SingleFile Layout = "single"
+	// SplitFiles generates the client in several files of the same package:
+	// the main file declares the helper types, then every schema namespace
+	// gets a file of types, named after the namespace with the _types.go
+	// suffix, and every port type a file of operations, named after it with
+	// the _service.go suffix.
+	SplitFiles Layout = "split"
+)
+
+// ParseLayout returns the layout named s, SingleFile when empty.
+func ParseLayout(s string) (Layout, error) {
+	switch l := Layout(s); l {
+	case "", SingleFile:
+		return SingleFile, nil
+	case SplitFiles:
+		return l, nil
+	}
+	return "", fmt.Errorf("unknown layout %q, should be %s or %s", s, SingleFile, SplitFiles)
+}
+
+// renderSplit renders the client of model laid out in SplitFiles, the main
+// file being named file. Every file is formatted separately, without the
+// imports it doesn't use. Failures are returned as a *DiagnosticError, along
+// with the files rendered.
+func renderSplit(model *Model, overrides Templates, file string) ([]*File, error) {
+	if err := overrides.check(); err != nil {
+		return nil, templateError(err)
+	}
+	code := &Code{positions: make(map[string]Position)}
+	for _, t := range model.Types {
+		code.positions[t.Name] = t.Pos
+	}
+
+	header, err := genHeader(model, overrides)
+	if err != nil {
+		return nil, templateError(fmt.Errorf("generating header: %w", err))
+	}
+	names := map[string]bool{file: true}
+	main, err := code.format(header)
+	files := []*File{{Name: file, Content: main}}
+	if err != nil {
+		return files, err
+	}
+
+	var namespaces []string
+	types := make(map[string][]*Type)
+	for _, t := range model.Types {
+		ns := t.QName.Space
+		if _, ok := types[ns]; !ok {
+			namespaces = append(namespaces, ns)
+		}
+		types[ns] = append(types[ns], t)
+	}
+	for _, ns := range namespaces {
+		part := *model
+		part.Types, part.Services = types[ns], nil
+		body, err := genTypes(&part, overrides)
+		if err != nil {
+			return files, templateError(fmt.Errorf("generating types of %s: %w", ns, err))
+		}
+		f, err := code.splitFile(&part, overrides, uniqueFileName(names, fileSlug(namespaceFileName(ns)), "types"), body)
+		files = append(files, f)
+		if err != nil {
+			return files, err
+		}
+	}
+
+	for _, s := range model.Services {
+		part := *model
+		part.Types, part.Services = nil, []*Service{s}
+		body, err := genOperations(&part, overrides)
+		if err != nil {
+			return files, templateError(fmt.Errorf("generating operations of %s: %w", s.Name, err))
+		}
+		f, err := code.splitFile(&part, overrides, uniqueFileName(names, snakeCase(s.Name), "service"), body)
+		files = append(files, f)
+		if err != nil {
+			return files, err
+		}
+	}
+	return files, nil
+}
+
+// splitFile returns the file name made of the header of model followed by
+// body.
+func (c *Code) splitFile(model *Model, overrides Templates, name string, body []byte) (*File, error) {
+	header, err := genFileHeader(model, overrides)
+	if err != nil {
+		return &File{Name: name}, templateError(fmt.Errorf("generating header of %s: %w", name, err))
+	}
+	source, err := c.format(header, body)
+	return &File{Name: name, Content: source}, err
+}
+
+// namespaceFileName returns the part of namespace naming its file: the host
+// and path of URLs, without the www. prefix, or the URN as is.
+func namespaceFileName(namespace string) string {
+	if i := strings.Index(namespace, "://"); i >= 0 {
+		namespace = strings.TrimPrefix(namespace[i+3:], "www.")
+	}
+	return namespace
+}
+
+// fileSlug lower cases s, replacing the runs of characters other than letters
+// and digits by underscores.
+func fileSlug(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		} else if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+			b.WriteRune('_')
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// uniqueFileName returns the name of the Go file made of base and suffix,
+// numbered when taken by names, and adds it to names. The suffix keeps the
+// name from ending like a test file or a file constrained to an operating
+// system or architecture.
+func uniqueFileName(names map[string]bool, base, suffix string) string {
+	if base != "" {
+		suffix = "_" + suffix
+	}
+	name := base + suffix + ".go"
+	for i := 2; names[name]; i++ {
+		name = base + strconv.Itoa(i) + suffix + ".go"
+	}
+	names[name] = true
+	return name
+}
+
+// templateError reports err failing to render a template.
+func templateError(err error) error {
+	return &DiagnosticError{Diagnostics: []Diagnostic{{Severity: SeverityError, Code: CodeTemplate, Message: err.Error(), Err: err}}}
+}
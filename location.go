@@ -5,16 +5,23 @@
 package gowsdl
 
 import (
+	"fmt"
+	"io/fs"
 	"net/url"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
 // A Location encapsulate information about the loc of WSDL/XSD.
 //
-// It could be either URL or an absolute file path.
+// It could be either URL, an absolute file path or a path within an fs.FS.
 type Location struct {
 	u *url.URL
 	f string
+	// fsys is the filesystem p is a path of.
+	fsys fs.FS
+	p    string
 }
 
 // ParseLocation parses a rawloc into a Location structure.
@@ -24,7 +31,7 @@ type Location struct {
 func ParseLocation(rawloc string) (*Location, error) {
 	u, _ := url.Parse(rawloc)
 	if u.Scheme != "" {
-		return &Location{u: u}, nil
+		return urlLocation(u), nil
 	}
 
 	absURI, err := filepath.Abs(rawloc)
@@ -35,15 +42,35 @@ func ParseLocation(rawloc string) (*Location, error) {
 	return &Location{f: absURI}, nil
 }
 
+// ParseFSLocation returns the Location of name within fsys. Relative
+// references from it resolve within fsys too, unless they are absolute URLs.
+func ParseFSLocation(fsys fs.FS, name string) (*Location, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return &Location{fsys: fsys, p: name}, nil
+}
+
 // Parse parses path in the context of the receiver. The provided path may be relative or absolute.
 // Parse returns nil, err on parse failure.
 func (r *Location) Parse(ref string) (*Location, error) {
+	if r.isFS() {
+		if u, err := url.Parse(ref); err == nil && u.Scheme != "" {
+			return urlLocation(u), nil
+		}
+		p := path.Join(path.Dir(r.p), ref)
+		if !fs.ValidPath(p) {
+			return nil, fmt.Errorf("%s referenced by %s is outside the filesystem", ref, r.p)
+		}
+		return &Location{fsys: r.fsys, p: p}, nil
+	}
+
 	if r.u != nil {
 		u, err := r.u.Parse(ref)
 		if err != nil {
 			return nil, err
 		}
-		return &Location{u: u}, nil
+		return urlLocation(u), nil
 	}
 
 	if filepath.IsAbs(ref) {
@@ -52,23 +79,58 @@ func (r *Location) Parse(ref string) (*Location, error) {
 
 	if u, err := url.Parse(ref); err == nil {
 		if u.Scheme != "" {
-			return &Location{u: u}, nil
+			return urlLocation(u), nil
 		}
 	}
 
 	return &Location{f: filepath.Join(filepath.Dir(r.f), ref)}, nil
 }
 
+// urlLocation returns the Location of u, which is a file path for file URLs.
+func urlLocation(u *url.URL) *Location {
+	if u.Scheme == "file" {
+		return &Location{f: filepath.FromSlash(u.Path)}
+	}
+	return &Location{u: u}
+}
+
 // IsFile determines whether the Location contains a file path.
 func (r *Location) isFile() bool {
 	return r.f != ""
 }
 
+// isFS determines whether the Location contains a path within an fs.FS.
+func (r *Location) isFS() bool {
+	return r.fsys != nil
+}
+
 // IsFile determines whether the Location contains URL.
 func (r *Location) isURL() bool {
 	return r.u != nil
 }
 
+// canonical returns a normalized form of the location, so that different
+// references to the same document compare equal.
+func (r *Location) canonical() string {
+	if r.isFile() {
+		return filepath.Clean(r.f)
+	}
+	if r.isURL() {
+		u := *r.u
+		u.Scheme = strings.ToLower(u.Scheme)
+		u.Host = strings.ToLower(u.Host)
+		u.Fragment = ""
+		if u.Path != "" {
+			u.Path = path.Clean(u.Path)
+		}
+		return u.String()
+	}
+	if r.isFS() {
+		return "fs:" + r.p
+	}
+	return ""
+}
+
 // String reassembles the Location either into a valid URL string or a file path.
 func (r *Location) String() string {
 	if r.isFile() {
@@ -77,5 +139,8 @@ func (r *Location) String() string {
 	if r.isURL() {
 		return r.u.String()
 	}
+	if r.isFS() {
+		return r.p
+	}
 	return ""
 }
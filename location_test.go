@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 )
 
 func TestLocation_ParseLocation_URL(t *testing.T) {
@@ -145,3 +146,79 @@ func TestLocation_Parse_FileToURL(t *testing.T) {
 		}
 	}
 }
+
+func TestLocation_Parse_FS(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		expected string
+	}{
+		{"wsdl/my.wsdl", "some.xsd", "wsdl/some.xsd"},
+		{"wsdl/my.wsdl", "../xsd/some.xsd", "xsd/some.xsd"},
+		{"my.wsdl", "xsd/./some.xsd", "xsd/some.xsd"},
+		{"my.wsdl", "../some.xsd", ""},
+	}
+	fsys := fstest.MapFS{}
+	for _, test := range tests {
+		r, err := ParseFSLocation(fsys, test.name)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		r, err = r.Parse(test.ref)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("%s should be outside the filesystem, got %s", test.ref, r)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !r.isFS() || r.isFile() || r.isURL() {
+			t.Error("Location should be a FS type")
+			continue
+		}
+		if r.String() != test.expected {
+			t.Error("got " + r.String() + " wanted " + test.expected)
+		}
+	}
+
+	r, err := ParseFSLocation(fsys, "my.wsdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err = r.Parse("http://example.org/some.xsd"); err != nil || !r.isURL() {
+		t.Errorf("absolute URLs should resolve outside the filesystem, got %v, %v", r, err)
+	}
+}
+
+func TestLocation_canonical(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		expected string
+	}{
+		{"HTTP://Example.org/folder/my.wsdl", "./some.xsd#types", "http://example.org/folder/some.xsd"},
+		{"http://example.org/folder/my.wsdl", "sub//some.xsd", "http://example.org/folder/sub/some.xsd"},
+		{"http://example.org/folder/my.wsdl", "some.xsd?wsdl", "http://example.org/folder/some.xsd?wsdl"},
+	}
+	for _, test := range tests {
+		r, err := ParseLocation(test.name)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		r, err = r.Parse(test.ref)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if r.canonical() != test.expected {
+			t.Error("got " + r.canonical() + " wanted " + test.expected)
+		}
+	}
+}
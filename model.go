@@ -0,0 +1,546 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Model is the intermediate representation of the code generated from a WSDL:
+// its services, and the Go types of the messages they exchange. Names,
+// pointers and slices are resolved when the model is built, so the templates,
+// and any other backend, only render it.
+type Model struct {
+	// Package is the name of the generated package.
+	Package string `json:"package"`
+	// SOAPImport is the import path of the SOAP runtime.
+	SOAPImport string `json:"soapImport"`
+	// Imports are the packages of the mapped types the types use.
+	Imports         []*Import `json:"imports,omitempty"`
+	TargetNamespace string    `json:"targetNamespace,omitempty"`
+	// WSDL is the source of the WSDL document, served by the generated
+	// server.
+	WSDL string `json:"wsdl,omitempty"`
+
+	// Types lists the Go types in declaration order.
+	Types    []*Type    `json:"types"`
+	Services []*Service `json:"services"`
+
+	// rendered is the Code last rendered from the model, shared by the Go
+	// backends, see renderShared.
+	rendered *rendering
+}
+
+// Type returns the Go type named name, or nil.
+func (m *Model) Type(name string) *Type {
+	for _, t := range m.Types {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Service returns the service named name, or nil.
+func (m *Model) Service(name string) *Service {
+	for _, s := range m.Services {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// TypeKind tells how a Type is declared in Go.
+type TypeKind string
+
+const (
+	// StructType is a struct of Fields.
+	StructType TypeKind = "struct"
+	// DefinedType is a type whose underlying type is Underlying, such as
+	// simple types and elements of another type. Simple types restricted to
+	// a set of values declare them as Enums.
+	DefinedType TypeKind = "defined"
+	// ArrayType is a SOAP encoded array, a slice of the Underlying type
+	// marshalled with items named Item.
+	ArrayType TypeKind = "array"
+	// RPCType is the struct wrapping the parts of an RPC style message, each
+	// part being one of its Fields.
+	RPCType TypeKind = "rpc"
+	// InterfaceType is an abstract element substituted by the elements of
+	// its substitution group: an interface implemented by the Go types of
+	// the Substitutes. A slice of it, named GroupName, holds the elements of
+	// the group in the fields referencing the abstract element, decoding them
+	// by name or xsi:type.
+	InterfaceType TypeKind = "interface"
+)
+
+// Type is a Go type generated for a schema component or an RPC message.
+type Type struct {
+	Name string   `json:"name"`
+	Kind TypeKind `json:"kind"`
+	// QName is the name of the simple type, complex type or element the type
+	// is generated for.
+	QName xml.Name `json:"qname"`
+	Doc   string   `json:"doc,omitempty"`
+	Pos   Position `json:"pos"`
+
+	// XMLName is the element a struct is marshalled as, if any.
+	XMLName xml.Name `json:"xmlName"`
+	Fields  []*Field `json:"fields,omitempty"`
+
+	// Underlying is the Go type of defined types and the slice type of SOAP
+	// arrays.
+	Underlying string  `json:"underlying,omitempty"`
+	Enums      []*Enum `json:"enums,omitempty"`
+	// Facets constrain the values of a DefinedType restricting a simple
+	// type, checked by its Validate method.
+	Facets *Facets `json:"facets,omitempty"`
+	// Validated is set for the defined types and SOAP arrays whose Underlying
+	// type, or the type of its items, has a Validate method.
+	Validated bool `json:"validated,omitempty"`
+	// Item is the name of the items of a SOAP array.
+	Item xml.Name `json:"item"`
+
+	// EncodingStyle is set for the RPC wrappers of use="encoded" messages.
+	EncodingStyle string `json:"encodingStyle,omitempty"`
+
+	// Substitutes are the concrete elements of the substitution group of an
+	// InterfaceType, substituting it directly or through other abstract
+	// elements.
+	Substitutes []*Substitute `json:"substitutes,omitempty"`
+	// GroupName and SubstitutesName are the names of the slice and the
+	// soap.SubstitutionGroup generated for an InterfaceType, counted up when
+	// a type of the schemas has them.
+	GroupName       string `json:"groupName,omitempty"`
+	SubstitutesName string `json:"substitutesName,omitempty"`
+
+	// Subtypes are the types derived by extension from a struct, directly or
+	// not, embedding it. An interface named after it with the Any prefix is
+	// implemented by the struct and its subtypes, and a struct named after
+	// it with the Value suffix holds any of them in the fields of its type,
+	// telling them apart by xsi:type.
+	Subtypes []*Subtype `json:"subtypes,omitempty"`
+	// AnyName, ValueName and HierarchyName are the names of the interface,
+	// the struct and the soap.TypeHierarchy generated for a struct with
+	// Subtypes, counted up when a type of the schemas has them.
+	AnyName       string `json:"anyName,omitempty"`
+	ValueName     string `json:"valueName,omitempty"`
+	HierarchyName string `json:"hierarchyName,omitempty"`
+
+	// Choices lists the names of the Fields of a struct that are the
+	// alternatives of a choice, at most one of which may be set.
+	Choices [][]string `json:"choices,omitempty"`
+}
+
+// Substitute is an element of a substitution group.
+type Substitute struct {
+	// Element is the name of the element and Type the name of its schema
+	// type, empty when anonymous.
+	Element xml.Name `json:"element"`
+	Type    xml.Name `json:"type"`
+	// GoType is the Go type of the element, implementing the interface.
+	GoType string `json:"goType"`
+}
+
+// Subtype is a complex type derived from another one.
+type Subtype struct {
+	// Type is the name of the schema type, announced through xsi:type.
+	Type xml.Name `json:"type"`
+	// GoType is the Go type of the schema type.
+	GoType string `json:"goType"`
+}
+
+// Field is a field of a struct.
+type Field struct {
+	// Name is the name of the field, the name of the type for embedded
+	// fields.
+	Name string `json:"name,omitempty"`
+	// Type is the Go type of the field, including slice and pointer. It is
+	// empty when the field is an anonymous struct of Fields.
+	Type string `json:"type,omitempty"`
+	// Tag is the struct tag, without quotes.
+	Tag string `json:"tag,omitempty"`
+	Doc string `json:"doc,omitempty"`
+
+	// XMLName is the name of the element or attribute the field is
+	// marshalled as.
+	XMLName xml.Name `json:"xmlName"`
+	// Attr is set for attributes, Chardata for the value of simple content
+	// and Any for the elements matched by xs:any.
+	Attr     bool `json:"attr,omitempty"`
+	Chardata bool `json:"chardata,omitempty"`
+	Any      bool `json:"any,omitempty"`
+	// Embedded is set for the base type of an extension.
+	Embedded bool `json:"embedded,omitempty"`
+	// Repeated is set for elements occurring more than once.
+	Repeated bool `json:"repeated,omitempty"`
+	// MinOccurs and MaxOccurs bound the occurrences of an element, MaxOccurs
+	// being 0 when unbounded. The alternatives of a choice may not occur.
+	MinOccurs int `json:"minOccurs,omitempty"`
+	MaxOccurs int `json:"maxOccurs,omitempty"`
+	// Required is set for the elements of a positive MinOccurs and the
+	// attributes of use="required".
+	Required bool `json:"required,omitempty"`
+	// Group is set for the references to an abstract element, whose Type
+	// holds the elements of its substitution group.
+	Group bool `json:"group,omitempty"`
+	// Polymorphic is set for the elements of a type other types derive
+	// from, whose Type holds a value of any of them.
+	Polymorphic bool `json:"polymorphic,omitempty"`
+
+	// Facets constrain the values of the restricted simple content and the
+	// elements and attributes of local simple types, checked by the Validate
+	// method of the struct.
+	Facets *Facets `json:"facets,omitempty"`
+	// Validated is set for the fields whose type has a Validate method, and
+	// the anonymous structs with constraints to check.
+	Validated bool `json:"validated,omitempty"`
+
+	// Struct is set for elements of an anonymous complex type, declared as a
+	// struct of Fields, the alternatives of its choices being Choices.
+	Struct  bool       `json:"struct,omitempty"`
+	Fields  []*Field   `json:"fields,omitempty"`
+	Choices [][]string `json:"choices,omitempty"`
+
+	// XSIType is the type of an RPC part announced in encoded messages.
+	XSIType xml.Name `json:"xsiType"`
+}
+
+// Omittable reports whether the zero value of the Go type of f, nil or empty,
+// stands for an absent value, which numbers, booleans and structs can't.
+func (f *Field) Omittable() bool {
+	switch {
+	case f.Group:
+		return true
+	case strings.HasPrefix(f.Type, "*"), strings.HasPrefix(f.Type, "[]"), strings.HasPrefix(f.Type, "map["):
+		return true
+	}
+	return f.Type == "string" || f.Type == "interface{}"
+}
+
+// Facets are the constraining facets of a simple type restriction.
+type Facets struct {
+	Enumeration []string `json:"enumeration,omitempty"`
+	// Pattern is the pattern translated to the syntax of the regexp
+	// package, anchored.
+	Pattern      string `json:"pattern,omitempty"`
+	MinInclusive string `json:"minInclusive,omitempty"`
+	MaxInclusive string `json:"maxInclusive,omitempty"`
+	// Length, MinLength and MaxLength are zero when unconstrained.
+	Length    int `json:"length,omitempty"`
+	MinLength int `json:"minLength,omitempty"`
+	MaxLength int `json:"maxLength,omitempty"`
+}
+
+// Enum is a value of a simple type restricted to a set of values.
+type Enum struct {
+	// Name is the name of the constant declared for the value.
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Doc   string `json:"doc,omitempty"`
+}
+
+// Service is generated for a port type: an interface declaring its
+// operations, implemented by a client.
+type Service struct {
+	// Name is the name of the interface, and Impl the name of its
+	// implementation.
+	Name  string   `json:"name"`
+	Impl  string   `json:"impl"`
+	QName xml.Name `json:"qname"`
+	Doc   string   `json:"doc,omitempty"`
+	Pos   Position `json:"pos"`
+	// SOAPVersion is "1.1" or "1.2".
+	SOAPVersion string `json:"soapVersion"`
+	// Address is the location of the first port serving the port type, if
+	// any.
+	Address    string       `json:"address,omitempty"`
+	Operations []*Operation `json:"operations"`
+}
+
+// Operation is an operation of a Service.
+type Operation struct {
+	// Name is the name of the method invoking the operation.
+	Name       string   `json:"name"`
+	QName      xml.Name `json:"qname"`
+	Doc        string   `json:"doc,omitempty"`
+	Pos        Position `json:"pos"`
+	SOAPAction string   `json:"soapAction,omitempty"`
+	// Input and Output are nil when the operation has no such message, or
+	// when it has no Go type.
+	Input  *Message `json:"input,omitempty"`
+	Output *Message `json:"output,omitempty"`
+	Faults []*Fault `json:"faults,omitempty"`
+}
+
+// Message is the input or output of an Operation.
+type Message struct {
+	// Name is the name of the WSDL message.
+	Name string `json:"name"`
+	// Type is the Go type of the body, without pointer.
+	Type    string    `json:"type"`
+	Headers []*Header `json:"headers,omitempty"`
+}
+
+// Header is a message part bound to the SOAP header.
+type Header struct {
+	Message string `json:"message"`
+	Part    string `json:"part"`
+	// Type is the Go type of the part, without pointer. It is empty when the
+	// part doesn't resolve.
+	Type string `json:"type,omitempty"`
+}
+
+// Fault is a fault an Operation may return.
+type Fault struct {
+	Name    string `json:"name"`
+	Doc     string `json:"doc,omitempty"`
+	Message string `json:"message"`
+	// Type is the Go type of the fault detail, without pointer. It is empty
+	// when the message doesn't resolve.
+	Type string `json:"type,omitempty"`
+}
+
+// Model loads the WSDL and resolves the model of the code generated for it,
+// without generating the code.
+//
+// Problems found along the way are reported by Diagnostics, and make Model
+// fail like Start.
+func (g *GoWSDL) Model() (*Model, error) {
+	if err := g.load(); err != nil {
+		g.fail(CodeLoad, Position{}, err)
+		return nil, g.failure()
+	}
+	return g.buildModel(), g.failure()
+}
+
+// load reads the WSDL, resolves the references between the components of its
+// schemas and names them.
+func (g *GoWSDL) load() error {
+	if err := g.read(); err != nil {
+		return err
+	}
+	g.index()
+	return nil
+}
+
+// read reads the WSDL and resolves the references between the components of
+// its schemas.
+func (g *GoWSDL) read() error {
+	if err := g.unmarshal(); err != nil {
+		return err
+	}
+	if g.filter != nil {
+		g.filterOperations()
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		newTraverser(schema, g.wsdl.Types.Schemas).traverse()
+	}
+	return nil
+}
+
+// index names the components of the schemas and synthesizes the RPC wrappers.
+// The components of the schemas shared through a common package are named by
+// it. With an operation filter, the components the selected operations reach
+// are collected too.
+func (g *GoWSDL) index() {
+	schemas := g.wsdl.Types.Schemas
+	var external map[symbol]string
+	if g.common != nil {
+		schemas, external = g.common.split(g)
+	}
+	g.substitutions = collectSubstitutions(g.wsdl.Types.Schemas)
+	g.derivations = collectDerivations(g.wsdl.Types.Schemas)
+	g.symbols = newSymbolTable(schemas, g.typeName, g.typeMap, external, g.substitutions)
+	g.claimHierarchies(schemas)
+	g.claimGroups(schemas)
+	g.collectRPCWrappers()
+	if g.filter != nil {
+		g.reachableSymbols = g.reachable()
+	}
+}
+
+func (g *GoWSDL) buildModel() *Model {
+	m := &Model{
+		Package:         g.pkg,
+		SOAPImport:      g.soapImport,
+		TargetNamespace: g.wsdl.TargetNamespace,
+		WSDL:            string(g.rawWSDL),
+		Types:           g.buildTypes(),
+	}
+	for _, pt := range g.wsdl.PortTypes {
+		m.Services = append(m.Services, g.buildService(pt))
+	}
+	m.Imports = g.imports(m)
+	return m
+}
+
+func (g *GoWSDL) buildService(pt *WSDLPortType) *Service {
+	s := &Service{
+		Name:        g.typeName(pt.Name),
+		QName:       xml.Name{Space: g.wsdl.TargetNamespace, Local: pt.Name},
+		Doc:         pt.Doc,
+		Pos:         pt.Pos,
+		SOAPVersion: g.findSOAPVersion(pt.Name),
+		Address:     g.findPortTypeAddress(pt.Name),
+	}
+	if s.Impl = unexport(s.Name); s.Impl == s.Name {
+		s.Impl += "Client"
+	}
+
+	for _, op := range pt.Operations {
+		o := &Operation{
+			Name:       g.naming.Operation(op.Name),
+			QName:      xml.Name{Space: g.wsdl.TargetNamespace, Local: op.Name},
+			Doc:        op.Doc,
+			Pos:        op.Pos,
+			SOAPAction: g.findSOAPAction(op.Name, pt.Name),
+			Input:      g.buildMessage(op.Input.Message),
+			Output:     g.buildMessage(op.Output.Message),
+		}
+
+		if binding, bop := g.findBindingOperation(op.Name, pt.Name); bop != nil {
+			input, output := bop.Input.SOAPHeader, bop.Output.SOAPHeader
+			if binding.IsSOAP12() {
+				input, output = bop.Input.SOAP12Header, bop.Output.SOAP12Header
+			}
+			if o.Input != nil {
+				o.Input.Headers = g.buildHeaders(input)
+			}
+			if o.Output != nil {
+				o.Output.Headers = g.buildHeaders(output)
+			}
+		}
+
+		for _, fault := range op.Faults {
+			f := &Fault{Name: fault.Name, Doc: fault.Doc, Message: stripns(fault.Message)}
+			if f.Message != "" {
+				f.Type = g.findType(fault.Message)
+			}
+			o.Faults = append(o.Faults, f)
+		}
+
+		s.Operations = append(s.Operations, o)
+	}
+	return s
+}
+
+// buildMessage returns the message named message, or nil when its Go type
+// can't be resolved.
+func (g *GoWSDL) buildMessage(message string) *Message {
+	if message == "" {
+		return nil
+	}
+	goType := g.findType(message)
+	if goType == "" {
+		return nil
+	}
+	return &Message{Name: stripns(message), Type: goType}
+}
+
+func (g *GoWSDL) buildHeaders(headers []*WSDLSOAPHeader) []*Header {
+	var hs []*Header
+	for _, header := range headers {
+		h := &Header{Message: stripns(header.Message), Part: header.Part}
+		if msg := g.findMessage(h.Message); msg != nil {
+			for _, part := range msg.Parts {
+				if part.Name == header.Part {
+					h.Type, _ = g.partType(msg, part)
+				}
+			}
+		}
+		hs = append(hs, h)
+	}
+	return hs
+}
+
+// findPortTypeAddress returns the location of the first port bound to
+// portType.
+func (g *GoWSDL) findPortTypeAddress(portType string) string {
+	for _, service := range g.wsdl.Service {
+		for _, port := range service.Ports {
+			for _, binding := range g.wsdl.Binding {
+				if binding.Name != stripns(port.Binding) || !strings.EqualFold(stripns(binding.Type), portType) {
+					continue
+				}
+				if port.SOAP12Address.Location != "" {
+					return port.SOAP12Address.Location
+				}
+				if port.SOAPAddress.Location != "" {
+					return port.SOAPAddress.Location
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// imports returns the packages of the mapped types, and of the types of the
+// common package, used by m, sorted by path.
+func (g *GoWSDL) imports(m *Model) []*Import {
+	packages := g.typeMap.packages()
+	if g.common != nil {
+		packages[g.common.name] = g.common.path
+	}
+	if len(packages) == 0 {
+		return nil
+	}
+
+	used := make(map[string]bool)
+	use := func(goType string) {
+		if q := (GoType{Type: goType}).qualifier(); packages[q] != "" {
+			used[q] = true
+		}
+	}
+	var useFields func(fields []*Field)
+	useFields = func(fields []*Field) {
+		for _, f := range fields {
+			use(f.Type)
+			useFields(f.Fields)
+		}
+	}
+	for _, t := range m.Types {
+		use(t.Underlying)
+		useFields(t.Fields)
+	}
+	for _, s := range m.Services {
+		for _, op := range s.Operations {
+			for _, msg := range []*Message{op.Input, op.Output} {
+				if msg == nil {
+					continue
+				}
+				use(msg.Type)
+				for _, h := range msg.Headers {
+					use(h.Type)
+				}
+			}
+			for _, f := range op.Faults {
+				use(f.Type)
+			}
+		}
+	}
+
+	imports := make([]*Import, 0, len(used))
+	for q := range used {
+		imp := &Import{Path: packages[q]}
+		if path.Base(imp.Path) != q {
+			imp.Name = q
+		}
+		imports = append(imports, imp)
+	}
+	sort.Slice(imports, func(i, j int) bool { return imports[i].Path < imports[j].Path })
+	return imports
+}
+
+// isQualified reports whether goType is qualified by a package name, such as
+// the mapped types and the types of the common package.
+func isQualified(goType string) bool {
+	return (GoType{Type: goType}).qualifier() != ""
+}
@@ -0,0 +1,437 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestModel(t *testing.T) {
+	g, err := New("fixtures/rpc.wsdl", WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := g.Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Package != "myservice" || m.SOAPImport != defaultSOAPImport {
+		t.Errorf("got package %s importing %s", m.Package, m.SOAPImport)
+	}
+
+	s := m.Service("MachinePort")
+	if s == nil {
+		t.Fatal("MachinePort service is missing")
+	}
+	if s.Impl != "machinePort" || s.SOAPVersion != "1.1" || s.Address != "http://example.com/machines" {
+		t.Errorf("got service %+v", s)
+	}
+	if len(s.Operations) != 1 {
+		t.Fatalf("got %d operations, want 1", len(s.Operations))
+	}
+	op := s.Operations[0]
+	if op.Name != "Describe" || op.SOAPAction != "urn:calculator#describe" {
+		t.Errorf("got operation %+v", op)
+	}
+	if op.Input == nil || op.Input.Type != "Describe" || op.Output == nil || op.Output.Type != "DescribeResponse" {
+		t.Errorf("got input %+v and output %+v, want the RPC wrappers", op.Input, op.Output)
+	}
+
+	wrapper := m.Type("Describe")
+	if wrapper == nil || wrapper.Kind != RPCType {
+		t.Fatalf("got %+v, want the RPC wrapper of describe", wrapper)
+	}
+	if wrapper.XMLName != (xml.Name{Space: "urn:machines", Local: "describe"}) || wrapper.EncodingStyle != soapEncodingNamespace {
+		t.Errorf("got wrapper %+v", wrapper)
+	}
+	if len(wrapper.Fields) != 1 {
+		t.Fatalf("got %d fields, want 1", len(wrapper.Fields))
+	}
+	part := wrapper.Fields[0]
+	if part.Name != "Machine" || part.Type != "*Machine" || part.XSIType != (xml.Name{Space: "urn:calculator", Local: "Machine"}) {
+		t.Errorf("got part %+v", part)
+	}
+
+	// The wrapper of the response of add is renamed, as a type of the schema
+	// has its name.
+	add := m.Service("CalculatorPort")
+	if add == nil || len(add.Operations) != 1 || add.Operations[0].Output == nil || add.Operations[0].Output.Type != "AddResponseElement" {
+		t.Fatalf("got %+v, want add to return the renamed wrapper", add)
+	}
+	if wrapper := m.Type("AddResponseElement"); wrapper == nil || wrapper.Kind != RPCType || wrapper.XMLName.Local != "addResponse" {
+		t.Errorf("got %+v, want the RPC wrapper of the response of add", wrapper)
+	}
+	if response := m.Type("AddResponse"); response == nil || response.Kind != StructType {
+		t.Errorf("got %+v, want the type of the schema", response)
+	}
+
+	array := m.Type("ArrayOfString")
+	if array == nil || array.Kind != ArrayType || array.Underlying != "[]string" {
+		t.Errorf("got %+v, want a SOAP array of strings", array)
+	}
+
+	machine := m.Type("Machine")
+	if machine == nil || machine.Kind != StructType || len(machine.Fields) != 2 {
+		t.Fatalf("got %+v, want a struct of 2 fields", machine)
+	}
+	cores := machine.Fields[1]
+	if cores.Name != "Cores" || cores.Type != "int32" || cores.XMLName.Local != "cores" || cores.Tag != `xml:"cores,omitempty" json:"cores,omitempty"` {
+		t.Errorf("got field %+v", cores)
+	}
+}
+
+func TestModelHeaders(t *testing.T) {
+	g, err := New("fixtures/ferry.wsdl", WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := g.Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := m.Service("WSFX0020ScheduleSoap")
+	if s == nil {
+		t.Fatal("WSFX0020ScheduleSoap service is missing")
+	}
+	for _, op := range s.Operations {
+		if op.Name != "GetAllAlerts" {
+			continue
+		}
+		if op.Input == nil || len(op.Input.Headers) != 1 {
+			t.Fatalf("got input %+v, want one header", op.Input)
+		}
+		h := op.Input.Headers[0]
+		if h.Message != "GetAllAlertsAPIAccessHeader" || h.Part != "APIAccessHeader" || h.Type != "APIAccessHeader" {
+			t.Errorf("got header %+v", h)
+		}
+		return
+	}
+	t.Error("GetAllAlerts operation is missing")
+}
+
+func TestModelGroups(t *testing.T) {
+	g, err := New("fixtures/groups/groups.wsdl", WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := g.Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Groups are inlined where they are referenced, their elements keeping
+	// the namespace and the types of the schema declaring them, and their
+	// choices following the sequence. The recursive group is inlined once.
+	want := []struct {
+		name, typ, space string
+		attr             bool
+	}{
+		{"ID", "string", "", false},
+		{"Email", "*EmailAddress", "urn:example:common", false},
+		{"Phone", "string", "urn:example:common", false},
+		{"Street", "string", "urn:example:common", false},
+		{"City", "string", "urn:example:common", false},
+		{"Total", "float64", "", false},
+		{"Note", "string", "", false},
+		{"Card", "string", "", false},
+		{"Invoice", "string", "", false},
+		{"Status", "string", "", true},
+		{"CreatedBy", "string", "", true},
+		{"Version", "*VersionNumber", "", true},
+	}
+	order := m.Type("Order")
+	if order == nil {
+		t.Fatal("Order type is missing")
+	}
+	if len(order.Fields) != len(want) {
+		t.Fatalf("got %d fields, want %d", len(order.Fields), len(want))
+	}
+	for i, f := range order.Fields {
+		w := want[i]
+		if f.Name != w.name || f.Type != w.typ || f.XMLName.Space != w.space || f.Attr != w.attr {
+			t.Errorf("got field %d %s %s in %q (attr %v), want %+v", i, f.Name, f.Type, f.XMLName.Space, f.Attr, w)
+		}
+	}
+
+	response := m.Type("PlaceOrderResponse")
+	if response == nil || len(response.Fields) != 2 || response.Fields[0].Name != "Street" || response.Fields[1].Name != "City" {
+		t.Errorf("got %+v, want the fields of the Address group", response)
+	}
+}
+
+func TestModelSubstitutionGroups(t *testing.T) {
+	g, err := New("fixtures/substitution/shapes.wsdl", WithOperationFilter(OperationFilter{Include: []string{"Draw"}}), WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := g.Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Triangle substitutes Shape through the abstract Polygon.
+	shape := m.Type("Shape")
+	if shape == nil || shape.Kind != InterfaceType {
+		t.Fatalf("got %+v, want the Shape interface", shape)
+	}
+	var substitutes []string
+	for _, s := range shape.Substitutes {
+		substitutes = append(substitutes, s.Element.Local+":"+s.GoType)
+	}
+	if got := strings.Join(substitutes, " "); got != "Circle:Circle Square:Square Triangle:Triangle" {
+		t.Errorf("got substitutes %s", got)
+	}
+	if s := shape.Substitutes[0]; s.Type != (xml.Name{Space: "urn:example:shapes", Local: "CircleType"}) {
+		t.Errorf("got type %v of Circle", s.Type)
+	}
+	for _, name := range []string{"Circle", "Square", "Triangle"} {
+		if m.Type(name) == nil {
+			t.Errorf("type %s of a substitute is missing", name)
+		}
+	}
+	if polygon := m.Type("Polygon"); polygon == nil || polygon.Kind != InterfaceType || len(polygon.Substitutes) != 1 {
+		t.Errorf("got %+v, want the Polygon interface", polygon)
+	}
+
+	// PolygonGroup is an element of the schema, so the slice holding the
+	// group of Polygon is renamed.
+	if polygon := m.Type("Polygon"); polygon == nil || polygon.GroupName != "PolygonGroup2" || polygon.SubstitutesName != "polygonSubstitutes" {
+		t.Errorf("got %+v, want the group of Polygon renamed", polygon)
+	}
+	response := m.Type("DrawResponse")
+	if response == nil || len(response.Fields) != 2 || response.Fields[0].Type != "PolygonGroup2" || response.Fields[1].Type != "*PolygonGroup" {
+		t.Errorf("got %+v, want the fields of the group of Polygon and of the PolygonGroup element", response)
+	}
+
+	// Abstract elements nothing substitutes are generated as before.
+	if unused := m.Type("Unused"); unused == nil || unused.Kind != DefinedType {
+		t.Errorf("got %+v, want a defined type", unused)
+	}
+
+	draw := m.Type("Draw")
+	if draw == nil || len(draw.Fields) != 3 {
+		t.Fatalf("got %+v, want the Draw struct", draw)
+	}
+	f := draw.Fields[1]
+	if f.Name != "Shape" || f.Type != "ShapeGroup" || !f.Group || !f.Repeated || f.Tag != `xml:",any,omitempty" json:"Shape,omitempty"` {
+		t.Errorf("got field %+v", f)
+	}
+	if f := draw.Fields[2]; f.Group || f.Type != "*Unused" {
+		t.Errorf("got field %+v", f)
+	}
+}
+
+func TestModelDerivedTypes(t *testing.T) {
+	g, err := New("fixtures/derivation/pets.wsdl", WithOperationFilter(OperationFilter{Include: []string{"Adopt"}}), WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := g.Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Puppy derives from Pet through Dog, and Cat is reached through Pet.
+	pet := m.Type("Pet")
+	if pet == nil || pet.Kind != StructType {
+		t.Fatalf("got %+v, want the Pet struct", pet)
+	}
+	var subtypes []string
+	for _, s := range pet.Subtypes {
+		subtypes = append(subtypes, s.Type.Local+":"+s.GoType)
+	}
+	if got := strings.Join(subtypes, " "); got != "Dog:Dog Puppy:Puppy Cat:Cat" {
+		t.Errorf("got subtypes %s", got)
+	}
+	if dog := m.Type("Dog"); dog == nil || len(dog.Subtypes) != 1 || !dog.Fields[0].Embedded {
+		t.Errorf("got %+v, want the Dog struct embedding Pet", dog)
+	}
+	for _, name := range []string{"Puppy", "Cat", "Owner"} {
+		if typ := m.Type(name); typ == nil || len(typ.Subtypes) != 0 {
+			t.Errorf("got %+v, want the %s struct without subtypes", typ, name)
+		}
+	}
+
+	adopt := m.Type("Adopt")
+	if adopt == nil || len(adopt.Fields) != 3 {
+		t.Fatalf("got %+v, want the Adopt struct", adopt)
+	}
+	// DogValue is a type of the schema, so the struct holding any Dog is
+	// renamed.
+	if dog := m.Type("Dog"); dog == nil || dog.AnyName != "AnyDog" || dog.ValueName != "DogValue2" || dog.HierarchyName != "dogHierarchy" {
+		t.Errorf("got %+v, want the hierarchy of Dog renamed", dog)
+	}
+	if budget := m.Type("Owner").Fields[1]; budget.Type != "*DogValue" || budget.Polymorphic {
+		t.Errorf("got field %+v, want the DogValue struct of the schema", budget)
+	}
+	for i, want := range []string{"*Owner", "[]*PetValue", "*DogValue2"} {
+		f := adopt.Fields[i]
+		if f.Type != want || f.Polymorphic != (i > 0) {
+			t.Errorf("got field %+v, want type %s", f, want)
+		}
+	}
+}
+
+func TestModelRestrictions(t *testing.T) {
+	g, err := New("fixtures/restriction/orders.wsdl", WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := g.Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fieldNames := func(typ *Type) string {
+		var names []string
+		for _, f := range typ.Fields {
+			names = append(names, f.Name+":"+f.Type)
+		}
+		return strings.Join(names, " ")
+	}
+
+	// The simple content keeps the value of Money and its attributes, but the
+	// prohibited one, and gets the facets of the restriction.
+	euros := m.Type("Euros")
+	if euros == nil {
+		t.Fatal("Euros is missing")
+	}
+	if got := fieldNames(euros); got != "Value:float64 Currency:string Rate:float64" {
+		t.Errorf("got Euros fields %s", got)
+	}
+	if f := euros.Fields[0]; !f.Chardata || f.Facets == nil || f.Facets.MinInclusive != "0" || f.Facets.MaxInclusive != "1000" {
+		t.Errorf("got value %+v", f)
+	}
+
+	// The complex content restates the elements it keeps, and keeps the
+	// attributes of Address.
+	if address := m.Type("LocalAddress"); address == nil || fieldNames(address) != "Street:string City:string ID:string" {
+		t.Errorf("got %+v, want the restricted fields of Address", address)
+	}
+
+	note := m.Type("Note")
+	if note == nil || fieldNames(note) != "Text:string Lang:string" {
+		t.Fatalf("got %+v, want the fields of the restriction of anyType", note)
+	}
+	// The pattern using a character class subtraction is left out.
+	if f := note.Fields[0].Facets; f == nil || f.MinLength != 1 || f.Pattern != "" {
+		t.Errorf("got facets %+v", f)
+	}
+	if f := note.Fields[1].Facets; f == nil || strings.Join(f.Enumeration, " ") != "en fr" {
+		t.Errorf("got facets %+v", f)
+	}
+	var warned bool
+	for _, d := range g.Diagnostics() {
+		warned = warned || d.Code == CodePattern && d.Severity == SeverityWarning
+	}
+	if !warned {
+		t.Errorf("unsupported patterns should be reported, got %v", g.Diagnostics())
+	}
+
+	if sku := m.Type("Sku"); sku == nil || sku.Facets == nil || sku.Facets.Pattern != `^(?:[A-Z]{2}-\d{4})$` || sku.Facets.MaxLength != 7 {
+		t.Errorf("got %+v, want the facets of Sku", sku)
+	}
+	if zip := m.Type("ZipCode"); zip == nil || zip.Facets == nil || zip.Facets.Pattern != `^(?:\d{5}|\d{5}-\d{4})$` {
+		t.Errorf("got %+v, want the patterns of ZipCode as alternatives", zip)
+	}
+}
+
+func TestModelOccurrences(t *testing.T) {
+	g, err := New("fixtures/validation/bookings.wsdl", WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := g.Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	constraints := func(fields []*Field) string {
+		var c []string
+		for _, f := range fields {
+			c = append(c, fmt.Sprintf("%s:%v:%d..%d:%v", f.Name, f.Required, f.MinOccurs, f.MaxOccurs, f.Validated))
+		}
+		return strings.Join(c, " ")
+	}
+
+	// The alternatives of the choice are not required, and the numeric
+	// maxOccurs repeats Seat.
+	passenger := m.Type("Passenger")
+	if passenger == nil {
+		t.Fatal("Passenger is missing")
+	}
+	if got, want := constraints(passenger.Fields), "Name:true:1..1:false Seat:false:0..2:false Phone:false:0..1:false Email:false:0..1:false ID:true:0..0:false"; got != want {
+		t.Errorf("got Passenger constraints %s, want %s", got, want)
+	}
+	if seat := passenger.Fields[1]; !seat.Repeated || seat.Type != "[]string" {
+		t.Errorf("got seat %+v, want a repeated element", seat)
+	}
+	if got := fmt.Sprint(passenger.Choices); got != "[[Phone Email]]" {
+		t.Errorf("got choices %s", got)
+	}
+
+	// The anonymous Extras has constraints to check.
+	book := m.Type("Book")
+	if book == nil {
+		t.Fatal("Book is missing")
+	}
+	if got, want := constraints(book.Fields), "Code:true:1..1:true Passenger:true:1..0:true Extras:false:0..1:true"; got != want {
+		t.Errorf("got Book constraints %s, want %s", got, want)
+	}
+	if code := m.Type("Code"); code == nil || code.Validated || code.Facets == nil {
+		t.Errorf("got %+v, want Code facets on a builtin type", code)
+	}
+
+	// Every choice is checked on its own, and the alternatives of a repeated
+	// choice are repeated.
+	route := m.Type("Route")
+	if route == nil {
+		t.Fatal("Route is missing")
+	}
+	if got := fmt.Sprint(route.Choices); got != "[[From FromCode] [To ToCode]]" {
+		t.Errorf("got Route choices %s", got)
+	}
+	var types []string
+	for _, f := range route.Fields {
+		types = append(types, f.Name+" "+f.Type)
+	}
+	if got, want := strings.Join(types, ", "), "From string, FromCode *Code, To string, ToCode *Code, Via []string, ViaCode []*Code"; got != want {
+		t.Errorf("got Route fields %s, want %s", got, want)
+	}
+
+	// Fields aren't named after the Validate method.
+	if cancel := m.Type("Cancel"); cancel == nil || len(cancel.Fields) != 2 || cancel.Fields[1].Name != "Validate_" {
+		t.Errorf("got %+v, want the validate element renamed", cancel)
+	}
+}
+
+func TestFieldOmittable(t *testing.T) {
+	tests := []struct {
+		field Field
+		want  bool
+	}{
+		{Field{Type: "string"}, true},
+		{Field{Type: "*Code"}, true},
+		{Field{Type: "[]int32"}, true},
+		{Field{Type: "interface{}"}, true},
+		{Field{Type: "Choice", Group: true}, true},
+		{Field{Type: "int32"}, false},
+		{Field{Type: "bool"}, false},
+		{Field{Type: "soap.XSDDateTime"}, false},
+		{Field{Struct: true}, false},
+	}
+	for _, test := range tests {
+		if got := test.field.Omittable(); got != test.want {
+			t.Errorf("got %v for %+v, want %v", got, test.field, test.want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// Naming derives the identifiers of the generated code from the names
+// declared by the WSDL and its schemas.
+type Naming interface {
+	// Type names the Go type of a schema type or element, of an RPC
+	// wrapper, or the interface of a port type.
+	Type(name string) string
+	// Field names the struct field of an element, an attribute or a
+	// message part.
+	Field(name string) string
+	// Enum names the constant of the enumeration value of the type named
+	// typeName.
+	Enum(typeName, value string) string
+	// Operation names the method of an operation.
+	Operation(name string) string
+}
+
+// CommonInitialisms are the initialisms GoNaming upper cases, those of golint.
+var CommonInitialisms = []string{
+	"ACL", "API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP",
+	"HTTPS", "ID", "IP", "JSON", "LHS", "QPS", "RAM", "RHS", "RPC", "SLA",
+	"SMTP", "SQL", "SSH", "TCP", "TLS", "TTL", "UDP", "UI", "UID", "UUID",
+	"URI", "URL", "UTF8", "VM", "XML", "XMPP", "XSRF", "XSS",
+}
+
+var commonInitialisms = make(map[string]bool, len(CommonInitialisms))
+
+func init() {
+	for _, s := range CommonInitialisms {
+		commonInitialisms[s] = true
+	}
+}
+
+// GoNaming names identifiers following the Go conventions, the default. Names
+// are split into words at separators, such as "-", "_" or ".", and at case
+// changes, then camel cased with initialisms upper cased: "customer_id" and
+// "customerId" become CustomerID, "HttpUrl" becomes HTTPURL. Names starting
+// with a digit are prefixed with X, enumeration values excepted since they
+// follow the name of their type. Enumeration constants of empty values end
+// with EmptyString.
+type GoNaming struct {
+	// Initialisms are upper cased in addition to CommonInitialisms, e.g.
+	// "EPC" or "SKU".
+	Initialisms []string
+}
+
+// Type implements Naming.
+func (n GoNaming) Type(name string) string {
+	return n.identifier(name)
+}
+
+// Field implements Naming.
+func (n GoNaming) Field(name string) string {
+	return n.identifier(name)
+}
+
+// Operation implements Naming.
+func (n GoNaming) Operation(name string) string {
+	return n.identifier(name)
+}
+
+// Enum implements Naming.
+func (n GoNaming) Enum(typeName, value string) string {
+	s := n.camel(value)
+	if s == "" {
+		s = "EmptyString"
+	}
+	return typeName + s
+}
+
+// identifier returns the exported identifier of name.
+func (n GoNaming) identifier(name string) string {
+	s := n.camel(name)
+	switch {
+	case s == "":
+		return "EmptyString"
+	case startsWithDigit(s):
+		return "X" + s
+	}
+	return s
+}
+
+// camel returns the words of name camel cased, initialisms upper cased.
+// Numbers stay separated by underscores, e.g. V1_14 for "v1.14".
+func (n GoNaming) camel(name string) string {
+	var b strings.Builder
+	for i, word := range words(name) {
+		if i > 0 && startsWithDigit(word) && strings.IndexFunc(b.String()[b.Len()-1:], unicode.IsDigit) == 0 {
+			b.WriteByte('_')
+		}
+		if upper := strings.ToUpper(word); n.isInitialism(upper) {
+			b.WriteString(upper)
+			continue
+		}
+		r := []rune(word)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
+
+func startsWithDigit(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) == 0
+}
+
+func (n GoNaming) isInitialism(word string) bool {
+	if commonInitialisms[word] {
+		return true
+	}
+	for _, s := range n.Initialisms {
+		if strings.EqualFold(s, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// words splits name into words at the characters other than letters and
+// digits, and at case changes: before an upper case letter following a lower
+// case one, and before the last letter of a run of upper case ones followed
+// by a lower case one, so "HTTPServer" splits into HTTP and Server. The
+// characters + and @ become the words Plus and At.
+func words(name string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '+' || r == '@':
+			flush()
+			words = append(words, specialCharacterMapping[string(r)])
+			continue
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(word) > 0:
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// LegacyNaming names identifiers like the previous versions of gowsdl: the
+// first letter is upper cased, and the characters other than letters, digits
+// and underscores dropped, "." and "-" becoming underscores. Names that are
+// Go keywords get an underscore suffix.
+type LegacyNaming struct{}
+
+// Type implements Naming.
+func (LegacyNaming) Type(name string) string {
+	return makePublic(replaceReservedWords(name))
+}
+
+// Field implements Naming.
+func (LegacyNaming) Field(name string) string {
+	return makePublic(replaceAttrReservedWords(name))
+}
+
+// Enum implements Naming.
+func (LegacyNaming) Enum(typeName, value string) string {
+	return typeName + makePublic(replaceReservedWords(value))
+}
+
+// Operation implements Naming.
+func (LegacyNaming) Operation(name string) string {
+	return replaceReservedWords(makePublic(name))
+}
+
+// ParseNaming returns the naming named style: "go", the default when empty,
+// with initialisms in addition to CommonInitialisms, or "legacy".
+func ParseNaming(style string, initialisms []string) (Naming, error) {
+	switch style {
+	case "", "go":
+		return GoNaming{Initialisms: initialisms}, nil
+	case "legacy":
+		if len(initialisms) > 0 {
+			return nil, errors.New("initialisms are only upper cased by the go naming")
+		}
+		return LegacyNaming{}, nil
+	}
+	return nil, fmt.Errorf("unknown naming %q, should be go or legacy", style)
+}
+
+// unexport returns the unexported counterpart of the identifier name, its
+// leading initialism lower cased, e.g. "httpClient" for "HTTPClient". Go
+// keywords get an underscore suffix.
+func unexport(name string) string {
+	runes := []rune(name)
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		i++
+	}
+	if i > 1 && i < len(runes) && unicode.IsLower(runes[i]) {
+		// The last upper case letter starts the next word.
+		i--
+	}
+	if i == 0 {
+		i = 1
+	}
+	for j := 0; j < i && j < len(runes); j++ {
+		runes[j] = unicode.ToLower(runes[j])
+	}
+	s := string(runes)
+	if token.Lookup(s).IsKeyword() {
+		s += "_"
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+)
+
+func TestGoNaming(t *testing.T) {
+	naming := GoNaming{Initialisms: []string{"epc"}}
+	tests := []struct {
+		name, want string
+	}{
+		{"customerId", "CustomerID"},
+		{"customer_id", "CustomerID"},
+		{"HttpUrl", "HTTPURL"},
+		{"get-info", "GetInfo"},
+		{"Get_info", "GetInfo"},
+		{"HTTPServer", "HTTPServer"},
+		{"xml.lang", "XMLLang"},
+		{"utf8Value", "UTF8Value"},
+		{"3DSecure", "X3DSecure"},
+		{"epcList", "EPCList"},
+		{"TradePriceRequest", "TradePriceRequest"},
+		{"a+b", "APlusB"},
+		{"", "EmptyString"},
+	}
+	for _, tt := range tests {
+		if got := naming.Type(tt.name); got != tt.want {
+			t.Errorf("Type(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+		if got := naming.Field(tt.name); got != tt.want {
+			t.Errorf("Field(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	enums := []struct {
+		value, want string
+	}{
+		{"v1.14", "VersionV1_14"},
+		{"1.0", "Version1_0"},
+		{"in-progress", "VersionInProgress"},
+		{"", "VersionEmptyString"},
+	}
+	for _, tt := range enums {
+		if got := naming.Enum("Version", tt.value); got != tt.want {
+			t.Errorf("Enum(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestUnexport(t *testing.T) {
+	for name, want := range map[string]string{
+		"StockQuotePortType": "stockQuotePortType",
+		"HTTPClient":         "httpClient",
+		"EPCISService":       "epcisService",
+		"ID":                 "id",
+		"Type":               "type_",
+	} {
+		if got := unexport(name); got != want {
+			t.Errorf("unexport(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestNaming(t *testing.T) {
+	g, err := New("fixtures/test.wsdl", WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := g.Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := m.Type("GetInfo").Fields[0]; f.Name != "ID" {
+		t.Errorf("got field %s, want ID", f.Name)
+	}
+
+	g, err = New("fixtures/test.wsdl", WithNaming(LegacyNaming{}), WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err = g.Model(); err != nil {
+		t.Fatal(err)
+	}
+	if f := m.Type("GetInfo").Fields[0]; f.Name != "Id" {
+		t.Errorf("got field %s with the legacy naming, want Id", f.Name)
+	}
+
+	if _, err := ParseNaming("legacy", []string{"EPC"}); err == nil {
+		t.Error("initialisms should fail with the legacy naming")
+	}
+	if _, err := ParseNaming("kebab", nil); err == nil {
+		t.Error("unknown namings should fail")
+	}
+}
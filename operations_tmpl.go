@@ -5,61 +5,59 @@
 package gowsdl
 
 var opsTmpl = `
-{{range .}}
-	{{$privateType := .Name | makePrivate}}
-	{{$exportType := .Name | makePublic}}
+{{define "Service"}}
+	{{$impl := .Impl}}
+	{{$soap12 := eq .SOAPVersion "1.2"}}
 
-	type {{$exportType}} interface {
+	type {{.Name}} interface {
 		{{range .Operations}}
-			{{$faults := len .Faults}}
-			{{$soapAction := findSOAPAction .Name $privateType}}
-			{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
-			{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
-
-			{{/*if ne $soapAction ""*/}}
-			{{if gt $faults 0}}
+			{{if .Faults}}
 			// Error can be either of the following types:
 			// {{range .Faults}}
 			//   - {{.Name}} {{.Doc}}{{end}}{{end}}
 			{{if ne .Doc ""}}/* {{.Doc}} */{{end}}
-			{{makePublic .Name | replaceReservedWords}} ({{if ne $requestType ""}}request *{{$requestType}}{{end}}) ({{if ne $responseType ""}}*{{$responseType}}, {{end}}error)
-			{{/*end*/}}
-			{{makePublic .Name | replaceReservedWords}}Context (ctx context.Context, {{if ne $requestType ""}}request *{{$requestType}}{{end}}) ({{if ne $responseType ""}}*{{$responseType}}, {{end}}error)
-			{{/*end*/}}
+			{{.Name}} ({{with .Input}}request *{{.Type}}{{end}}) ({{with .Output}}*{{.Type}}, {{end}}error)
+
+			{{.Name}}Context (ctx context.Context, {{with .Input}}request *{{.Type}}{{end}}) ({{with .Output}}*{{.Type}}, {{end}}error)
 		{{end}}
 	}
 
-	type {{$privateType}} struct {
+	type {{$impl}} struct {
 		client *soap.Client
 	}
 
-	func New{{$exportType}}(client *soap.Client) {{$exportType}} {
-		return &{{$privateType}}{
+	{{if $soap12}}
+	// New{{.Name}} returns a {{.Name}} bound with SOAP 1.2, which its calls
+	// speak whatever the version of client.
+	{{end}}
+	func New{{.Name}}(client *soap.Client) {{.Name}} {
+		return &{{$impl}}{
 			client: client,
 		}
 	}
 
 	{{range .Operations}}
-		{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
-		{{$soapAction := findSOAPAction .Name $privateType}}
-		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
-		func (service *{{$privateType}}) {{makePublic .Name | replaceReservedWords}}Context (ctx context.Context, {{if ne $requestType ""}}request *{{$requestType}}{{end}}) ({{if ne $responseType ""}}*{{$responseType}}, {{end}}error) {
-			{{if ne $responseType ""}}response := new({{$responseType}}){{end}}
-			err := service.client.CallContext(ctx, "{{if ne $soapAction ""}}{{$soapAction}}{{else}}''{{end}}", {{if ne $requestType ""}}request{{else}}nil{{end}}, {{if ne $responseType ""}}response{{else}}struct{}{}{{end}})
+		func (service *{{$impl}}) {{.Name}}Context (ctx context.Context, {{with .Input}}request *{{.Type}}{{end}}) ({{with .Output}}*{{.Type}}, {{end}}error) {
+			{{with .Output}}response := new({{.Type}}){{end}}
+			err := service.client.{{if $soap12}}CallContextWithVersion(ctx, soap.SOAP12, {{else}}CallContext(ctx, {{end}}"{{if ne .SOAPAction ""}}{{.SOAPAction}}{{else}}''{{end}}", {{if .Input}}request{{else}}nil{{end}}, {{if .Output}}response{{else}}struct{}{}{{end}})
 			if err != nil {
-				return {{if ne $responseType ""}}nil, {{end}}err
+				return {{if .Output}}nil, {{end}}err
 			}
 
-			return {{if ne $responseType ""}}response, {{end}}nil
+			return {{if .Output}}response, {{end}}nil
 		}
 
-		func (service *{{$privateType}}) {{makePublic .Name | replaceReservedWords}} ({{if ne $requestType ""}}request *{{$requestType}}{{end}}) ({{if ne $responseType ""}}*{{$responseType}}, {{end}}error) {
-			return service.{{makePublic .Name | replaceReservedWords}}Context(
+		func (service *{{$impl}}) {{.Name}} ({{with .Input}}request *{{.Type}}{{end}}) ({{with .Output}}*{{.Type}}, {{end}}error) {
+			return service.{{.Name}}Context(
 				context.Background(),
-				{{if ne $requestType ""}}request,{{end}}
+				{{if .Input}}request,{{end}}
 			)
 		}
 
 	{{end}}
 {{end}}
+
+{{range .Services}}
+	{{template "Service" .}}
+{{end}}
 `
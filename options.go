@@ -0,0 +1,194 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"strings"
+)
+
+// defaultSOAPImport is the import path of the SOAP runtime used by the
+// generated code.
+const defaultSOAPImport = "github.com/ParticleHealth/gowsdl/soap"
+
+// An Option configures the generator created by New.
+type Option func(*GoWSDL)
+
+// WithPackage sets the package name of the generated code, "myservice" by
+// default.
+func WithPackage(pkg string) Option {
+	return func(g *GoWSDL) {
+		g.pkg = strings.TrimSpace(pkg)
+	}
+}
+
+// WithSOAPImport sets the import path of the SOAP runtime used by the
+// generated code, e.g. a fork of the soap package. It is imported as soap,
+// whatever the name of its package.
+func WithSOAPImport(path string) Option {
+	return func(g *GoWSDL) {
+		g.soapImport = path
+	}
+}
+
+// WithTemplates overrides blocks of the templates Start renders the Go code
+// with, see Templates.
+func WithTemplates(templates Templates) Option {
+	return func(g *GoWSDL) {
+		g.templates = templates
+	}
+}
+
+// WithTypeMap maps XML Schema types to existing Go types, which are used
+// instead of generating types, see Config.
+func WithTypeMap(types TypeMap) Option {
+	return func(g *GoWSDL) {
+		g.typeMap = types
+	}
+}
+
+// WithOperationFilter restricts the generated operations to the ones filter
+// selects, and the generated types to the ones their messages use.
+func WithOperationFilter(filter OperationFilter) Option {
+	return func(g *GoWSDL) {
+		g.operationFilter = filter
+	}
+}
+
+// WithExportAllTypes sets whether the generated types are exported, which
+// they are by default.
+func WithExportAllTypes(export bool) Option {
+	return func(g *GoWSDL) {
+		g.unexportTypes = !export
+	}
+}
+
+// WithNaming sets how the identifiers of the generated code are derived from
+// the names of the WSDL, GoNaming by default.
+func WithNaming(naming Naming) Option {
+	return func(g *GoWSDL) {
+		g.naming = naming
+	}
+}
+
+// WithFS makes the WSDL file a path within fsys, such as an embed.FS.
+// Relative references from the WSDL and its schemas resolve within fsys.
+func WithFS(fsys fs.FS) Option {
+	return func(g *GoWSDL) {
+		g.fsys = fsys
+	}
+}
+
+// WithInsecureSkipVerify skips the verification of the certificates of the
+// servers remote documents are downloaded from.
+func WithInsecureSkipVerify(skip bool) Option {
+	return func(g *GoWSDL) {
+		g.ignoreTLS = skip
+	}
+}
+
+// WithFetchConfig sets how remote documents are downloaded.
+func WithFetchConfig(config FetchConfig) Option {
+	return func(g *GoWSDL) {
+		g.fetchConfig = config
+	}
+}
+
+// WithFetcher sets the Fetcher downloading remote documents, taking
+// precedence over WithFetchConfig and WithInsecureSkipVerify.
+func WithFetcher(fetcher Fetcher) Option {
+	return func(g *GoWSDL) {
+		g.fetcher = fetcher
+	}
+}
+
+// WithCatalog sets the catalog mapping the locations of documents, and the
+// namespaces imported without location, to local files.
+func WithCatalog(catalog *Catalog) Option {
+	return func(g *GoWSDL) {
+		g.catalog = catalog
+	}
+}
+
+// WithCacheDir sets the directory where downloaded documents are cached, e.g.
+// DefaultCacheDir. Documents are downloaded on every run by default, and an
+// empty dir disables the cache.
+func WithCacheDir(dir string) Option {
+	return func(g *GoWSDL) {
+		g.cache = newDownloadCache(dir)
+	}
+}
+
+// WithOffline sets whether remote documents are only read from the cache,
+// failing instead of being downloaded when missing.
+func WithOffline(offline bool) Option {
+	return func(g *GoWSDL) {
+		g.offline = offline
+	}
+}
+
+// WithStrict makes warnings, such as skipped messages or unresolved
+// references, fail the generation.
+func WithStrict(strict bool) Option {
+	return func(g *GoWSDL) {
+		g.strict = strict
+	}
+}
+
+// WithLogger sets the logger progress and warnings are written to, the
+// standard logger by default.
+func WithLogger(logger *log.Logger) Option {
+	return func(g *GoWSDL) {
+		g.logger = logger
+	}
+}
+
+// New initializes the generator of the Go code for the WSDL at file, a local
+// path or an URL.
+func New(file string, opts ...Option) (*GoWSDL, error) {
+	file = strings.TrimSpace(file)
+	if file == "" {
+		return nil, errors.New("WSDL file is required to generate Go proxy")
+	}
+
+	g := &GoWSDL{
+		pkg:        "myservice",
+		soapImport: defaultSOAPImport,
+		naming:     GoNaming{},
+		logger:     log.Default(),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if g.pkg == "" {
+		g.pkg = "myservice"
+	}
+	if g.naming == nil {
+		g.naming = GoNaming{}
+	}
+
+	var err error
+	if g.filter, err = g.operationFilter.compile(); err != nil {
+		return nil, err
+	}
+	if g.fsys != nil {
+		g.loc, err = ParseFSLocation(g.fsys, file)
+	} else {
+		g.loc, err = ParseLocation(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if g.fetcher == nil {
+		g.fetcher, err = g.fetchConfig.fetcher(g.ignoreTLS, g.loc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,276 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"errors"
+	"fmt"
+	"path"
+)
+
+// OutputDir returns the directory the packages of c are generated in.
+func (c *Config) OutputDir() string {
+	if c.Output == "" {
+		return c.dir
+	}
+	return c.path(c.Output)
+}
+
+// Generate generates the package of every input of c, returning the files
+// with their names relative to the output directory. opts apply to every
+// input, before the options set by c.
+//
+// The schema documents loaded from the same location by several inputs are
+// shared. With a common package, their types are generated once into it, and
+// the packages of the inputs import it.
+//
+// Problems are reported like by GoWSDL.Generate: when any is an error, or a
+// warning of a strict input, Generate returns a *DiagnosticError listing the
+// problems of all inputs, along with the files generated.
+func (c *Config) Generate(opts ...Option) ([]*File, error) {
+	if len(c.Inputs) == 0 {
+		return nil, errors.New("config has no inputs")
+	}
+	if c.Common != nil && c.Common.Import == "" {
+		return nil, errors.New("common package has no import path")
+	}
+
+	naming, err := ParseNaming(c.Naming, c.Initialisms)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts[:len(opts):len(opts)], WithNaming(naming))
+
+	gens := make([]*GoWSDL, 0, len(c.Inputs))
+	backends := make([][]Backend, 0, len(c.Inputs))
+	dirs := make([]string, 0, len(c.Inputs))
+	for _, in := range c.Inputs {
+		g, b, err := c.input(in, opts)
+		if err != nil {
+			return nil, fmt.Errorf("input %s: %w", in.WSDL, err)
+		}
+		gens = append(gens, g)
+		backends = append(backends, b)
+		dirs = append(dirs, packageDir(in.Dir, in.Package))
+	}
+
+	var loaded []*GoWSDL
+	failed := make(map[*GoWSDL]bool)
+	for _, g := range gens {
+		if err := g.read(); err != nil {
+			g.fail(CodeLoad, Position{}, err)
+			failed[g] = true
+			continue
+		}
+		loaded = append(loaded, g)
+	}
+
+	var files []*File
+	var common *commonPackage
+	if c.Common != nil {
+		types, err := c.TypeMap()
+		if err != nil {
+			return nil, err
+		}
+		common = newCommonPackage(c.Common, c.soapImport(nil), types, loaded)
+	}
+	if common != nil {
+		dir := packageDir(c.Common.Dir, common.name)
+		for _, f := range common.gen.generate(common.gen.buildModel(), []Backend{GoClient{}}) {
+			files = append(files, &File{Name: path.Join(dir, f.Name), Content: f.Content})
+		}
+	}
+
+	for i, g := range gens {
+		if failed[g] {
+			continue
+		}
+		g.common = common
+		g.index()
+		for _, f := range g.generate(g.buildModel(), backends[i]) {
+			files = append(files, &File{Name: path.Join(dirs[i], f.Name), Content: f.Content})
+		}
+	}
+
+	if common != nil {
+		gens = append(gens, common.gen)
+	}
+	var problems []Diagnostic
+	for _, g := range gens {
+		var de *DiagnosticError
+		if errors.As(g.failure(), &de) {
+			problems = append(problems, de.Diagnostics...)
+		}
+	}
+	if len(problems) > 0 {
+		return files, &DiagnosticError{Diagnostics: problems}
+	}
+	return files, nil
+}
+
+// input returns the generator of in and the backends generating its package.
+func (c *Config) input(in *Input, opts []Option) (*GoWSDL, []Backend, error) {
+	if in.Package == "" {
+		return nil, nil, errors.New("package is required")
+	}
+	types, err := c.typeMap(in.Types)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts = append(opts[:len(opts):len(opts)],
+		WithPackage(in.Package),
+		WithSOAPImport(c.soapImport(in)),
+		WithStrict(in.Strict),
+		WithTypeMap(types),
+		WithOperationFilter(OperationFilter{Include: in.Include, Exclude: in.Exclude}),
+	)
+	if in.Catalog != "" {
+		catalog, err := LoadCatalog(c.path(in.Catalog))
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, WithCatalog(catalog))
+	}
+	var templates Templates
+	if in.Templates != "" {
+		if templates, err = LoadTemplates(c.path(in.Templates)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	g, err := New(c.path(in.WSDL), opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	layout, err := ParseLayout(string(in.Layout))
+	if err != nil {
+		return nil, nil, err
+	}
+	backends := []Backend{GoClient{File: in.File, Templates: templates, Layout: layout}}
+	if in.Server {
+		server := GoServer{Templates: templates}
+		if in.File != "" {
+			server.File = "server" + in.File
+		}
+		backends = append(backends, server)
+	}
+	return g, backends, nil
+}
+
+// soapImport returns the import path of the SOAP runtime used by the package
+// of in, or by the common package when in is nil.
+func (c *Config) soapImport(in *Input) string {
+	switch {
+	case in != nil && in.SOAPImport != "":
+		return in.SOAPImport
+	case c.SOAPImport != "":
+		return c.SOAPImport
+	}
+	return defaultSOAPImport
+}
+
+// packageDir returns the directory of the package pkg, dir unless empty.
+func packageDir(dir, pkg string) string {
+	if dir != "" {
+		return path.Clean(dir)
+	}
+	return pkg
+}
+
+// commonPackage is the package the types of the schema documents shared by
+// several generators are generated in.
+type commonPackage struct {
+	// name and path are the name and the import path of the package.
+	name, path string
+	// gen generates the types of the shared schemas.
+	gen *GoWSDL
+	// shared are the documents loaded by several generators.
+	shared map[schemaKey]bool
+}
+
+// newCommonPackage returns the common package of the documents gens share,
+// or nil when they share none.
+func newCommonPackage(config *CommonPackage, soapImport string, types TypeMap, gens []*GoWSDL) *commonPackage {
+	if len(gens) < 2 {
+		return nil
+	}
+
+	count := make(map[schemaKey]int)
+	for _, g := range gens {
+		for key, node := range g.schemas.nodes {
+			if node.schema != nil {
+				count[key]++
+			}
+		}
+	}
+	shared := make(map[schemaKey]bool)
+	for key, n := range count {
+		if n > 1 {
+			shared[key] = true
+		}
+	}
+	if len(shared) == 0 {
+		return nil
+	}
+
+	name := config.Package
+	if name == "" {
+		name = "common"
+	}
+	common := &commonPackage{name: name, path: config.Import, shared: shared}
+	common.gen = &GoWSDL{
+		pkg:           name,
+		soapImport:    soapImport,
+		typeMap:       types,
+		naming:        gens[0].naming,
+		unexportTypes: gens[0].unexportTypes,
+		logger:        gens[0].logger,
+		wsdl:          new(WSDL),
+	}
+
+	// The schemas are taken from the first generator loading them, in the
+	// order it loaded them.
+	taken := make(map[schemaKey]bool)
+	for _, g := range gens {
+		keys := g.schemas.keys()
+		for _, schema := range g.wsdl.Types.Schemas {
+			if key, ok := keys[schema]; ok && shared[key] && !taken[key] {
+				taken[key] = true
+				common.gen.wsdl.Types.Schemas = append(common.gen.wsdl.Types.Schemas, schema)
+			}
+		}
+	}
+	common.gen.substitutions = collectSubstitutions(common.gen.wsdl.Types.Schemas)
+	common.gen.derivations = collectDerivations(common.gen.wsdl.Types.Schemas)
+	common.gen.symbols = newSymbolTable(common.gen.wsdl.Types.Schemas, common.gen.typeName, types, nil, common.gen.substitutions)
+	common.gen.claimHierarchies(common.gen.wsdl.Types.Schemas)
+	common.gen.claimGroups(common.gen.wsdl.Types.Schemas)
+	return common
+}
+
+// split returns the schemas of g generated in its own package, and the names
+// of the components generated in the common package instead, qualified by
+// its name.
+func (c *commonPackage) split(g *GoWSDL) ([]*XSDSchema, map[symbol]string) {
+	keys := g.schemas.keys()
+	g.shared = make(map[*XSDSchema]bool)
+
+	var own []*XSDSchema
+	for _, schema := range g.wsdl.Types.Schemas {
+		if key, ok := keys[schema]; ok && c.shared[key] {
+			g.shared[schema] = true
+			continue
+		}
+		own = append(own, schema)
+	}
+
+	external := make(map[symbol]string, len(c.gen.symbols.names))
+	for sym, name := range c.gen.symbols.names {
+		external[sym] = c.name + "." + name
+	}
+	return own, external
+}
@@ -0,0 +1,78 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigGenerate(t *testing.T) {
+	config, err := LoadConfig("fixtures/project/gowsdl.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := config.OutputDir(), filepath.Join("fixtures", "project", "gen"); got != want {
+		t.Errorf("got output directory %s, want %s", got, want)
+	}
+
+	files, err := config.Generate(WithCacheDir(""), WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated := make(map[string][]byte)
+	var names []string
+	for _, f := range files {
+		generated[f.Name] = f.Content
+		names = append(names, f.Name)
+	}
+	if got, want := strings.Join(names, " "), "common/common.go billing/billing.go shipping/shipping.go shipping/servershipping.go"; got != want {
+		t.Fatalf("got files %s, want %s", got, want)
+	}
+
+	for _, s := range []string{"type Address struct", "type CountryCode string", "type RequestHeader struct"} {
+		if !bytes.Contains(generated["common/common.go"], []byte(s)) {
+			t.Errorf("common package doesn't declare %s", s)
+		}
+	}
+	if bytes.Contains(generated["common/common.go"], []byte(`"context"`)) {
+		t.Error("common package imports context, which it doesn't use")
+	}
+
+	for _, name := range []string{"billing/billing.go", "shipping/shipping.go"} {
+		client := generated[name]
+		if !bytes.Contains(client, []byte(`"example.com/app/gen/common"`)) {
+			t.Errorf("%s doesn't import the common package", name)
+		}
+		if !bytes.Contains(client, []byte("Address *common.Address `")) {
+			t.Errorf("%s doesn't use common.Address", name)
+		}
+		if bytes.Contains(client, []byte("type Address struct")) {
+			t.Errorf("%s declares Address, want it in the common package only", name)
+		}
+	}
+}
+
+func TestConfigGenerateWithoutCommon(t *testing.T) {
+	config, err := LoadConfig("fixtures/project/gowsdl.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Common = nil
+
+	files, err := config.Generate(WithCacheDir(""), WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files[:2] {
+		if !bytes.Contains(f.Content, []byte("type Address struct")) {
+			t.Errorf("%s doesn't declare Address", f.Name)
+		}
+	}
+}
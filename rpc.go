@@ -0,0 +1,169 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+const soapEncodingNamespace = "http://schemas.xmlsoap.org/soap/encoding/"
+
+// findBindingOperation returns the binding operation used to invoke operation
+// of portType, along with its binding. SOAP 1.2 bindings are only considered
+// when the port type has no SOAP 1.1 binding, see findSOAPVersion.
+func (g *GoWSDL) findBindingOperation(operation, portType string) (*WSDLBinding, *WSDLOperation) {
+	for _, binding := range g.wsdl.Binding {
+		if strings.ToUpper(stripns(binding.Type)) != strings.ToUpper(portType) {
+			continue
+		}
+		if binding.IsSOAP12() && g.findSOAPVersion(portType) != "1.2" {
+			continue
+		}
+
+		for _, soapOp := range binding.Operations {
+			if soapOp.Name == operation {
+				return binding, soapOp
+			}
+		}
+	}
+	return nil, nil
+}
+
+// isRPC reports whether a binding operation uses the rpc style, either
+// declared on the operation itself or inherited from its binding.
+func isRPC(binding *WSDLBinding, op *WSDLOperation) bool {
+	style := op.SOAPOperation.Style
+	if binding.IsSOAP12() {
+		style = op.SOAP12Operation.Style
+	}
+	if style == "" {
+		style = binding.SOAPBinding.Style
+		if binding.IsSOAP12() {
+			style = binding.SOAP12Binding.Style
+		}
+	}
+	return style == "rpc"
+}
+
+// collectRPCWrappers synthesizes the wrapper elements of all RPC style
+// operations and indexes them by message name, so findType resolves those
+// messages to their wrappers. Document style messages name their element
+// through a single part, RPC style messages are wrapped in an element named
+// after the operation.
+func (g *GoWSDL) collectRPCWrappers() {
+	g.rpcWrappers = nil
+	g.rpcTypes = make(map[string]string)
+
+	for _, pt := range g.wsdl.PortTypes {
+		for _, op := range pt.Operations {
+			binding, bop := g.findBindingOperation(op.Name, pt.Name)
+			if bop == nil || !isRPC(binding, bop) {
+				continue
+			}
+
+			input, output := bop.Input.SOAPBody, bop.Output.SOAPBody
+			if binding.IsSOAP12() {
+				input, output = bop.Input.SOAP12Body, bop.Output.SOAP12Body
+			}
+			g.addRPCWrapper(op, op.Input.Message, op.Name, input)
+			g.addRPCWrapper(op, op.Output.Message, op.Name+"Response", output)
+		}
+	}
+}
+
+func (g *GoWSDL) addRPCWrapper(op *WSDLOperation, message, name string, body WSDLSOAPBody) {
+	message = stripns(message)
+	if message == "" {
+		return
+	}
+	if existing, ok := g.rpcTypes[message]; ok {
+		if existing != name {
+			g.warnf(CodeSharedRPCMessage, op.Pos, "message %s is shared by several RPC operations, using wrapper %s for all of them", message, existing)
+		}
+		return
+	}
+
+	t := &Type{
+		Kind: RPCType,
+		Pos:  op.Pos,
+	}
+	t.XMLName = xml.Name{Space: body.Namespace, Local: name}
+	if t.XMLName.Space == "" {
+		t.XMLName.Space = g.wsdl.TargetNamespace
+	}
+	t.QName = t.XMLName
+	// Wrappers are named after the types and elements of the schemas, and
+	// renamed when one of them has the same name.
+	sym := symbol{wrapperSymbol, t.XMLName}
+	g.symbols.claim(sym, g.typeName(name))
+	t.Name = g.symbols.names[sym]
+	if body.Use == "encoded" {
+		t.EncodingStyle = body.EncodingStyle
+		if t.EncodingStyle == "" {
+			t.EncodingStyle = soapEncodingNamespace
+		}
+	}
+
+	for _, msg := range g.wsdl.Messages {
+		if msg.Name != message {
+			continue
+		}
+		for _, part := range msg.Parts {
+			t.Fields = append(t.Fields, g.rpcPartField(part))
+		}
+	}
+
+	g.rpcTypes[message] = t.Name
+	g.rpcWrappers = append(g.rpcWrappers, t)
+}
+
+// rpcPartField returns the field of an RPC wrapper holding part. The resolved
+// QName of the part's XSD type is announced through xsi:type in encoded
+// messages.
+func (g *GoWSDL) rpcPartField(part *WSDLPart) *Field {
+	f := &Field{
+		Name:    g.fieldName(part.Name),
+		XMLName: xml.Name{Local: part.Name},
+		Tag:     fieldTag(xml.Name{Local: part.Name}, false),
+	}
+	if part.Type != "" {
+		typeName := parseQName(part.Type, g.wsdl.Xmlns)
+		f.Type = g.goType(typeSymbol, typeName, false, part.Pos)
+		f.Validated = g.validated(typeSymbol, typeName)
+		f.XSIType = typeName
+		return f
+	}
+
+	// Element parts are rendered with the type generated for the element.
+	f.Type = g.goType(elementSymbol, parseQName(part.Element, g.wsdl.Xmlns), false, part.Pos)
+	f.Validated = true
+	return f
+}
+
+// soapArrayItemType returns the item type of a SOAP encoded array, which is a
+// complex type restricting soapenc:Array, e.g.
+//
+//	<restriction base="soapenc:Array">
+//	  <attribute ref="soapenc:arrayType" wsdl:arrayType="xsd:string[]"/>
+//	</restriction>
+//
+// It returns an empty string for every other type.
+func soapArrayItemType(ct *XSDComplexType) string {
+	if stripns(ct.ComplexContent.Restriction.Base) != "Array" {
+		return ""
+	}
+	for _, attr := range ct.ComplexContent.Restriction.Attributes {
+		if attr.ArrayType != "" {
+			return strings.TrimRight(attr.ArrayType, "[],0123456789")
+		}
+	}
+	for _, elm := range ct.ComplexContent.Restriction.Sequence {
+		if elm.Type != "" {
+			return elm.Type
+		}
+	}
+	return "xsd:anyType"
+}
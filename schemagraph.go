@@ -0,0 +1,194 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// xmlNamespace is bound to the xml prefix by definition, schemas import it
+// without location to reference attributes like xml:lang.
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// schemaGraph holds the schema documents reached through xs:import and
+// xs:include. Documents are keyed by their canonical location and the
+// namespace they are expected to provide, so a schema without target namespace
+// included into several namespaces is loaded once per namespace.
+type schemaGraph struct {
+	nodes map[schemaKey]*schemaNode
+	// imports lists the xs:imports giving only a namespace. They are checked
+	// once all documents are loaded, see checkImports.
+	imports []namespaceImport
+}
+
+type schemaKey struct {
+	location  string
+	namespace string
+}
+
+type schemaNode struct {
+	location *Location
+	schema   *XSDSchema
+	// resolving is set while the dependencies of the document are being
+	// loaded. Reaching such a document again closes a cycle.
+	resolving bool
+}
+
+type namespaceImport struct {
+	namespace string
+	chain     []string
+	pos       Position
+}
+
+func newSchemaGraph() *schemaGraph {
+	return &schemaGraph{nodes: make(map[schemaKey]*schemaNode)}
+}
+
+// node returns the node of the document at location providing namespace, and
+// whether it was already added to the graph.
+func (sg *schemaGraph) node(location *Location, namespace string) (*schemaNode, bool) {
+	key := schemaKey{location: location.canonical(), namespace: namespace}
+	if node, ok := sg.nodes[key]; ok {
+		return node, true
+	}
+
+	node := &schemaNode{location: location, resolving: true}
+	sg.nodes[key] = node
+	return node, false
+}
+
+// keys returns the keys of the documents of the graph, by schema.
+func (sg *schemaGraph) keys() map[*XSDSchema]schemaKey {
+	keys := make(map[*XSDSchema]schemaKey, len(sg.nodes))
+	for key, node := range sg.nodes {
+		if node.schema != nil {
+			keys[node.schema] = key
+		}
+	}
+	return keys
+}
+
+// resolveXSDExternals loads the documents imported and included by schema,
+// which was read from loc, and adds them to the WSDL types. Each document is
+// loaded once and added after the documents it depends on, which are loaded
+// first, except for the documents closing a cycle.
+func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, loc *Location) error {
+	return g.resolveSchemaDependencies(schema, loc, []string{loc.String()})
+}
+
+func (g *GoWSDL) resolveSchemaDependencies(schema *XSDSchema, loc *Location, chain []string) error {
+	for _, impts := range schema.Imports {
+		// Download the file only if we have a hint in the form of schemaLocation.
+		// Otherwise the catalog may know where to find the namespace.
+		var err error
+		if impts.SchemaLocation != "" {
+			err = g.loadSchema(loc, impts.SchemaLocation, impts.Namespace, false, chain)
+		} else if location, ok := g.lookupCatalog(impts.Namespace); ok {
+			err = g.loadSchemaDocument(location, impts.Namespace, false, chain)
+		} else {
+			g.schemas.imports = append(g.schemas.imports, namespaceImport{namespace: impts.Namespace, chain: chain, pos: impts.Pos})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, incl := range schema.Includes {
+		if err := g.loadSchema(loc, incl.SchemaLocation, schema.TargetNamespace, true, chain); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadSchema loads the schema document ref, relative to base and mapped by the
+// catalog, which is expected to provide namespace. chain lists the documents
+// leading to it.
+func (g *GoWSDL) loadSchema(base *Location, ref, namespace string, include bool, chain []string) error {
+	location, err := g.locate(base, ref)
+	if err != nil {
+		return err
+	}
+	return g.loadSchemaDocument(location, namespace, include, chain)
+}
+
+func (g *GoWSDL) loadSchemaDocument(location *Location, namespace string, include bool, chain []string) error {
+	node, loaded := g.schemas.node(location, namespace)
+	if loaded {
+		if node.resolving {
+			g.logger.Printf("[INFO] Schema cycle %s", strings.Join(extendChain(chain, location.String()), " -> "))
+		}
+		return nil
+	}
+
+	data, err := g.fetchFile(location)
+	if err != nil {
+		return fmt.Errorf("unable to load schema %s referenced by %s: %w", location, strings.Join(chain, " -> "), err)
+	}
+	return g.parseSchemaDocument(node, data, namespace, include, chain)
+}
+
+// parseSchemaDocument reads the schema of node from data, loads its
+// dependencies and adds it to the WSDL types.
+func (g *GoWSDL) parseSchemaDocument(node *schemaNode, data []byte, namespace string, include bool, chain []string) error {
+	newschema := new(XSDSchema)
+	if err := decodeSource(node.location.String(), data, newschema); err != nil {
+		return fmt.Errorf("unable to parse schema %s: %w", node.location, err)
+	}
+
+	if include && newschema.TargetNamespace == "" {
+		// A schema without target namespace takes the one of the schema
+		// including it.
+		newschema.TargetNamespace = namespace
+		if _, ok := newschema.Xmlns[""]; !ok {
+			newschema.Xmlns[""] = namespace
+		}
+	}
+	if newschema.TargetNamespace != namespace {
+		g.warnf(CodeNamespaceMismatch, newschema.Pos, "Schema %s has target namespace %q, expected %q by %s", node.location, newschema.TargetNamespace, namespace, chain[len(chain)-1])
+	}
+	node.schema = newschema
+
+	if err := g.resolveSchemaDependencies(newschema, node.location, extendChain(chain, node.location.String())); err != nil {
+		return err
+	}
+	node.resolving = false
+
+	g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, newschema)
+	return nil
+}
+
+// checkImports warns about the xs:imports giving only a namespace that no
+// loaded schema provides.
+func (g *GoWSDL) checkImports() {
+	provided := make(map[string]bool)
+	for _, schema := range g.wsdl.Types.Schemas {
+		provided[schema.TargetNamespace] = true
+	}
+
+	warned := make(map[string]bool)
+	for _, imp := range g.schemas.imports {
+		switch {
+		case provided[imp.namespace], warned[imp.namespace]:
+		case imp.namespace == soapEncodingNamespace:
+			// SOAP encoding types are provided by the soap package.
+		case imp.namespace == xmlNamespace:
+		default:
+			g.warnf(CodeSchemaImport, imp.pos, "Don't know where to find XSD for %s imported by %s", imp.namespace, strings.Join(imp.chain, " -> "))
+			warned[imp.namespace] = true
+		}
+	}
+}
+
+// extendChain returns a copy of chain followed by location. Chains are kept
+// around for diagnostics, so they must not share their backing arrays.
+func extendChain(chain []string, location string) []string {
+	c := make([]string, len(chain), len(chain)+1)
+	copy(c, chain)
+	return append(c, location)
+}
@@ -1,9 +1,10 @@
 package gowsdl
 
 var serverHeaderTmpl = `
+{{define "ServerHeader"}}
 // Code generated by gowsdl DO NOT EDIT.
 
-package {{.}}
+package {{.Package}}
 
 import (
 	"fmt"
@@ -13,9 +14,11 @@ import (
 	"encoding/xml"
 	"net/http"
 
-	{{/*range .Imports*/}}
-		{{/*.*/}}
-	{{/*end*/}}
+	{{range .Imports}}{{with .Name}}{{.}} {{end}}"{{.Path}}"
+	{{end}}
 )
 
+{{end}}
+
+{{template "ServerHeader" .}}
 `
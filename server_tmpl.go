@@ -1,20 +1,28 @@
 package gowsdl
 
 var serverTmpl = `
+{{define "Server"}}
 
 var WSDLUndefinedError = errors.New("Server was unable to process request. --> Object reference not set to an instance of an object.")
 
+const (
+	soap11EnvelopeNS = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12EnvelopeNS = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// SOAPEnvelopeRequest accepts both SOAP 1.1 and SOAP 1.2 envelopes.
 type SOAPEnvelopeRequest struct {
-	XMLName xml.Name ` + "`" + `xml:"http://schemas.xmlsoap.org/soap/envelope/ Envelope"` + "`" + `
+	XMLName xml.Name ` + "`" + `xml:"Envelope"` + "`" + `
 	Body SOAPBodyRequest
 }
 
 type SOAPBodyRequest struct {
-	XMLName xml.Name ` + "`" + `xml:"http://schemas.xmlsoap.org/soap/envelope/ Body"` + "`" + `
-	{{range .}}
+	XMLName xml.Name ` + "`" + `xml:"Body"` + "`" + `
+	{{range .Services}}
 		{{range .Operations}}
-				{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}} ` + `
-  				{{$requestType}} *{{$requestType}} ` + "`" + `xml:,omitempty` + "`" + `
+			{{with .Input}}
+				{{unqualify .Type}} *{{.Type}} ` + "`" + `xml:",omitempty"` + "`" + `
+			{{end}}
 		{{end}}
 	{{end}}
 }
@@ -30,7 +38,7 @@ type SOAPEnvelopeResponse struct { ` + `
 
 func NewSOAPEnvelopResponse() *SOAPEnvelopeResponse {
 	return &SOAPEnvelopeResponse{
-		PrefixSoap: "http://schemas.xmlsoap.org/soap/envelope/",
+		PrefixSoap: soap11EnvelopeNS,
 		PrefixXsd:  "http://www.w3.org/2001/XMLSchema",
 		PrefixXsi:  "http://www.w3.org/2001/XMLSchema-instance",
 	}
@@ -46,34 +54,42 @@ type Fault struct { ` + `
 	Detail string    ` + "`" + `xml:"detail,omitempty"` + "`" + `
 }
 
+// Fault12 is the SOAP 1.2 counterpart of Fault.
+type Fault12 struct { ` + `
+	XMLName xml.Name ` + "`" + `xml:"soap:Fault"` + "`" + `
+
+	Code   string ` + "`" + `xml:"soap:Code>soap:Value"` + "`" + `
+	Reason string ` + "`" + `xml:"soap:Reason>soap:Text"` + "`" + `
+	Detail string ` + "`" + `xml:"soap:Detail,omitempty"` + "`" + `
+}
+
 
 type SOAPBodyResponse struct { ` + `
 	XMLName xml.Name   ` + "`" + `xml:"soap:Body"` + "`" + `
 	Fault   *Fault ` + "`" + `xml:",omitempty"` + "`" + `
-{{range .}}
+	Fault12 *Fault12 ` + "`" + `xml:",omitempty"` + "`" + `
+{{range .Services}}
 	{{range .Operations}}
-		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
-		{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}} ` + `
-			{{$requestType}} *{{$responseType}} ` + "`" + `xml:",omitempty"` + "`" + `
+		{{if and .Input .Output}}
+			{{unqualify .Input.Type}} *{{.Output.Type}} ` + "`" + `xml:",omitempty"` + "`" + `
+		{{end}}
 	{{end}}
 {{end}}
 
 }
 
-{{range .}}
+{{range .Services}}
 	{{range .Operations}}
-		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
-		{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
-		{{$requestTypeSource := findType .Input.Message | replaceReservedWords }}
-func (service *SOAPBodyRequest) {{$requestType}}Func(request *{{$requestType}}) (*{{$responseType}}, error) {
+		{{if and .Input .Output}}
+func (service *SOAPBodyRequest) {{unqualify .Input.Type}}Func(request *{{.Input.Type}}) (*{{.Output.Type}}, error) {
 	return nil, WSDLUndefinedError
 }
+		{{end}}
 	{{end}}
 {{end}}
 
 
 func (service *SOAPEnvelopeRequest) call(w http.ResponseWriter, r *http.Request) {
-	w.Header().Add("Content-Type", "text/xml; charset=utf-8")
 	val := reflect.ValueOf(&service.Body).Elem()
 	n := val.NumField()
 	var field reflect.Value
@@ -81,31 +97,42 @@ func (service *SOAPEnvelopeRequest) call(w http.ResponseWriter, r *http.Request)
 	find := false
 
 	if r.Method == http.MethodGet {
+		w.Header().Add("Content-Type", "text/xml; charset=utf-8")
 		w.Write([]byte(wsdl))
 		return
 	}
 
+	soap12 := strings.Contains(r.Header.Get("Content-Type"), "application/soap+xml")
 	resp := NewSOAPEnvelopResponse()
 	defer func() {
 		if r := recover(); r != nil {
-			resp.Body.Fault = &Fault{}
-			resp.Body.Fault.Space = "http://schemas.xmlsoap.org/soap/envelope/"
-			resp.Body.Fault.Code = "soap:Server"
-			resp.Body.Fault.Detail = fmt.Sprintf("%v", r)
-			resp.Body.Fault.String = fmt.Sprintf("%v", r)
+			if soap12 {
+				resp.Body.Fault12 = &Fault12{}
+				resp.Body.Fault12.Code = "soap:Receiver"
+				resp.Body.Fault12.Reason = fmt.Sprintf("%v", r)
+				resp.Body.Fault12.Detail = fmt.Sprintf("%v", r)
+			} else {
+				resp.Body.Fault = &Fault{}
+				resp.Body.Fault.Space = soap11EnvelopeNS
+				resp.Body.Fault.Code = "soap:Server"
+				resp.Body.Fault.Detail = fmt.Sprintf("%v", r)
+				resp.Body.Fault.String = fmt.Sprintf("%v", r)
+			}
+		}
+		if soap12 {
+			resp.PrefixSoap = soap12EnvelopeNS
+			w.Header().Add("Content-Type", "application/soap+xml; charset=utf-8")
+		} else {
+			w.Header().Add("Content-Type", "text/xml; charset=utf-8")
 		}
 		xml.NewEncoder(w).Encode(resp)
 	}()
 
-	header := r.Header.Get("Content-Type")
-	if strings.Index(header, "application/soap+xml") >= 0 {
-		panic("Could not find an appropriate Transport Binding to invoke.")
-	}
-
 	err := xml.NewDecoder(r.Body).Decode(service)
 	if err != nil {
 		panic(err)
 	}
+	soap12 = service.XMLName.Space == soap12EnvelopeNS
 
 	for i := 0; i < n; i++ {
 		field = val.Field(i)
@@ -145,4 +172,7 @@ func Endpoint(w http.ResponseWriter, r *http.Request) {
 	request.call(w, r)
 }
 
+{{end}}
+
+{{template "Server" .}}
 `
@@ -0,0 +1,142 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// document is a WSDL or XSD document read while loading the WSDL.
+type document struct {
+	location *Location
+	data     []byte
+	// refs maps the locations referenced by the document, as written, to the
+	// documents they resolved to.
+	refs map[string]*Location
+}
+
+// documentSet records the documents read while loading the WSDL, in the
+// order they were read, so they can be snapshot.
+type documentSet struct {
+	docs  []*document
+	byKey map[string]*document
+}
+
+func newDocumentSet() *documentSet {
+	return &documentSet{byKey: make(map[string]*document)}
+}
+
+func (ds *documentSet) add(loc *Location, data []byte) {
+	key := loc.canonical()
+	if _, ok := ds.byKey[key]; ok {
+		return
+	}
+	doc := &document{location: loc, data: data, refs: make(map[string]*Location)}
+	ds.docs = append(ds.docs, doc)
+	ds.byKey[key] = doc
+}
+
+// reference records that the document at base references ref, resolved to
+// target.
+func (ds *documentSet) reference(base *Location, ref string, target *Location) {
+	if doc, ok := ds.byKey[base.canonical()]; ok {
+		doc.refs[ref] = target
+	}
+}
+
+// Snapshot loads the WSDL and writes it, along with every WSDL and XSD
+// document it references, to dir. References between the documents are
+// rewritten to the relative paths of their copies, so the snapshot can be
+// generated from without network access. Snapshot returns the path of the
+// WSDL copy.
+//
+// Namespaces imported without location and resolved through the catalog are
+// copied too, but the snapshot still needs the catalog to find them.
+func (g *GoWSDL) Snapshot(dir string) (string, error) {
+	if err := g.unmarshal(); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	names := make(map[string]string, len(g.documents.docs))
+	taken := make(map[string]bool, len(g.documents.docs))
+	for _, doc := range g.documents.docs {
+		name := snapshotName(doc)
+		ext := path.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		for i := 2; taken[strings.ToLower(name)]; i++ {
+			name = fmt.Sprintf("%s_%d%s", base, i, ext)
+		}
+		taken[strings.ToLower(name)] = true
+		names[doc.location.canonical()] = name
+	}
+
+	for _, doc := range g.documents.docs {
+		data := locationAttr.ReplaceAllFunc(doc.data, func(attr []byte) []byte {
+			m := locationAttr.FindSubmatch(attr)
+			value := m[3][1 : len(m[3])-1]
+			target, ok := doc.refs[html.UnescapeString(string(value))]
+			if !ok {
+				return attr
+			}
+			name, ok := names[target.canonical()]
+			if !ok {
+				return attr
+			}
+			return []byte(fmt.Sprintf(`%s%s"%s"`, m[1], m[2], name))
+		})
+
+		file := filepath.Join(dir, names[doc.location.canonical()])
+		if err := ioutil.WriteFile(file, data, 0644); err != nil {
+			return "", err
+		}
+	}
+
+	return filepath.Join(dir, names[g.loc.canonical()]), nil
+}
+
+// locationAttr matches the attributes xs:import, xs:include and wsdl:import
+// reference documents with.
+var locationAttr = regexp.MustCompile(`\b(schemaLocation|location)(\s*=\s*)("[^"]*"|'[^']*')`)
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// snapshotName returns the file name of the copy of doc, derived from its
+// location.
+func snapshotName(doc *document) string {
+	var name string
+	if doc.location.isFile() {
+		name = filepath.Base(doc.location.f)
+	} else if doc.location.isFS() {
+		name = path.Base(doc.location.p)
+	} else {
+		name = path.Base(doc.location.u.Path)
+		if q := doc.location.u.RawQuery; q != "" {
+			name += "_" + q
+		}
+	}
+
+	name = strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), "._")
+	switch strings.ToLower(path.Ext(name)) {
+	case ".wsdl", ".xsd":
+		return name
+	}
+	if name == "" {
+		name = "document"
+	}
+	if isSchemaDocument(doc.data) {
+		return name + ".xsd"
+	}
+	return name + ".wsdl"
+}
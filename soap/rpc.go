@@ -0,0 +1,188 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+)
+
+const (
+	// XmlNsSoapEnc is the SOAP 1.1 encoding namespace, used as encodingStyle
+	// of RPC/encoded messages.
+	XmlNsSoapEnc string = "http://schemas.xmlsoap.org/soap/encoding/"
+	// XmlNsXSI is the XML Schema instance namespace carrying xsi:type.
+	XmlNsXSI string = "http://www.w3.org/2001/XMLSchema-instance"
+	// XmlNsXSD is the XML Schema namespace of the built-in types.
+	XmlNsXSD string = "http://www.w3.org/2001/XMLSchema"
+)
+
+// RPCPart is an accessor element of an RPC style message, holding the value
+// of one WSDL message part.
+type RPCPart struct {
+	Name string
+	// Type is announced through xsi:type when the message is SOAP encoded.
+	Type  xml.Name
+	Value interface{}
+}
+
+// prefixes assigns namespace prefixes to the names written by EncodeRPC and
+// MarshalArray. Go's encoder cannot write unqualified children below a
+// namespaced element, so the elements are prefixed by hand like SOAPEnvelope.
+type prefixes struct {
+	byNS   map[string]string
+	attrs  []xml.Attr
+	custom int
+}
+
+func (p *prefixes) qualify(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	if p.byNS == nil {
+		p.byNS = make(map[string]string)
+	}
+
+	prefix, ok := p.byNS[name.Space]
+	if !ok {
+		switch name.Space {
+		case XmlNsXSD:
+			prefix = "xsd"
+		case XmlNsXSI:
+			prefix = "xsi"
+		case XmlNsSoapEnc:
+			prefix = "soapenc"
+		default:
+			p.custom++
+			prefix = fmt.Sprintf("ns%d", p.custom)
+		}
+		p.byNS[name.Space] = prefix
+		p.attrs = append(p.attrs, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: name.Space})
+	}
+
+	return prefix + ":" + name.Local
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// EncodeRPC writes an RPC style message: a wrapper element named after the
+// operation holding one unqualified accessor element per part. Nil parts are
+// omitted. When encodingStyle is set, the message is SOAP encoded and every
+// part announces its Type through xsi:type.
+func EncodeRPC(e *xml.Encoder, name xml.Name, encodingStyle string, parts ...RPCPart) error {
+	var p prefixes
+	start := xml.StartElement{Name: xml.Name{Local: p.qualify(name)}}
+
+	types := make([]string, len(parts))
+	if encodingStyle != "" {
+		p.qualify(xml.Name{Space: XmlNsXSI, Local: "type"})
+		for i, part := range parts {
+			if part.Type.Local != "" {
+				types[i] = p.qualify(part.Type)
+			}
+		}
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "soap:encodingStyle"}, Value: encodingStyle})
+	}
+	start.Attr = append(p.attrs, start.Attr...)
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for i, part := range parts {
+		if isNil(part.Value) {
+			continue
+		}
+
+		partStart := xml.StartElement{Name: xml.Name{Local: part.Name}}
+		if types[i] != "" {
+			partStart.Attr = append(partStart.Attr, xml.Attr{Name: xml.Name{Local: "xsi:type"}, Value: types[i]})
+		}
+		if err := e.EncodeElement(part.Value, partStart); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// MarshalArray writes items, which must be a slice, as a SOAP encoded array
+// (soapenc:Array) whose elements are of type itemType. It is meant to be called
+// from the MarshalXML method of a generated array type.
+func MarshalArray(e *xml.Encoder, start xml.StartElement, itemType xml.Name, items interface{}) error {
+	rv := reflect.ValueOf(items)
+	if rv.Kind() != reflect.Slice {
+		return fmt.Errorf("soap: cannot marshal %T as SOAP encoded array", items)
+	}
+
+	var p prefixes
+	p.qualify(xml.Name{Space: XmlNsXSI, Local: "type"})
+	arrayType := p.qualify(xml.Name{Space: XmlNsSoapEnc, Local: "arrayType"})
+	array := p.qualify(xml.Name{Space: XmlNsSoapEnc, Local: "Array"})
+	item := p.qualify(itemType)
+
+	hasType := false
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "xsi:type" || (attr.Name.Space == XmlNsXSI && attr.Name.Local == "type") {
+			hasType = true
+		}
+	}
+	attrs := append(p.attrs, start.Attr...)
+	if !hasType {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "xsi:type"}, Value: array})
+	}
+	start.Attr = append(attrs, xml.Attr{
+		Name:  xml.Name{Local: arrayType},
+		Value: fmt.Sprintf("%s[%d]", item, rv.Len()),
+	})
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := e.EncodeElement(rv.Index(i).Interface(), xml.StartElement{Name: xml.Name{Local: "item"}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// UnmarshalArray reads a SOAP encoded array into items, which must be a
+// pointer to a slice. Every child element of start is decoded as one item,
+// regardless of its name.
+func UnmarshalArray(d *xml.Decoder, start xml.StartElement, items interface{}) error {
+	rv := reflect.ValueOf(items)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("soap: cannot unmarshal SOAP encoded array into %T", items)
+	}
+	slice := rv.Elem()
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			item := reflect.New(slice.Type().Elem())
+			if err := d.DecodeElement(item.Interface(), &t); err != nil {
+				return err
+			}
+			slice.Set(reflect.Append(slice, item.Elem()))
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"reflect"
 	"time"
 )
 
@@ -102,6 +103,9 @@ type SOAPBodyResponse struct {
 	// fault is initialized to non-nil with user-provided detail type.
 	faultOccurred bool
 	Fault         *SOAPFault `xml:",omitempty"`
+
+	// fault12 holds the fault when the response is a SOAP 1.2 envelope.
+	fault12 *SOAP12Fault
 }
 
 type MIMEMultipartAttachment struct {
@@ -135,7 +139,7 @@ Loop:
 		case xml.StartElement:
 			if consumed {
 				return xml.UnmarshalError("Found multiple elements inside SOAP body; not wrapped-document/literal WS-I compliant")
-			} else if se.Name.Space == "http://schemas.xmlsoap.org/soap/envelope/" && se.Name.Local == "Fault" {
+			} else if se.Name.Space == XmlNsSoapEnv && se.Name.Local == "Fault" {
 				b.Content = nil
 
 				b.faultOccurred = true
@@ -144,6 +148,20 @@ Loop:
 					return err
 				}
 
+				consumed = true
+			} else if se.Name.Space == XmlNsSoap12Env && se.Name.Local == "Fault" {
+				b.Content = nil
+
+				b.faultOccurred = true
+				b.fault12 = new(SOAP12Fault)
+				if b.Fault != nil {
+					b.fault12.Detail = b.Fault.Detail
+				}
+				err = d.DecodeElement(b.fault12, &se)
+				if err != nil {
+					return err
+				}
+
 				consumed = true
 			} else {
 				if err = d.DecodeElement(b.Content, &se); err != nil {
@@ -170,6 +188,9 @@ func (b *SOAPBody) ErrorFromFault() error {
 
 func (b *SOAPBodyResponse) ErrorFromFault() error {
 	if b.faultOccurred {
+		if b.fault12 != nil {
+			return b.fault12
+		}
 		return b.Fault
 	}
 	b.Fault = nil
@@ -233,6 +254,7 @@ const (
 	WssNsType       string = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordText"
 	mtomContentType string = `multipart/related; start-info="application/soap+xml"; type="application/xop+xml"; boundary="%s"`
 	XmlNsSoapEnv    string = "http://schemas.xmlsoap.org/soap/envelope/"
+	XmlNsSoap12Env  string = "http://www.w3.org/2003/05/soap-envelope"
 )
 
 type WSSSecurityHeader struct {
@@ -294,6 +316,8 @@ type options struct {
 	httpHeaders      map[string]string
 	mtom             bool
 	mma              bool
+	version          SOAPVersion
+	validate         bool
 }
 
 var defaultOptions = options{
@@ -375,6 +399,22 @@ func WithMIMEMultipartAttachments() Option {
 	}
 }
 
+// WithSOAPVersion is an Option to set the SOAP protocol version used for the
+// envelope, the Content-Type header and fault parsing. It defaults to SOAP11.
+func WithSOAPVersion(version SOAPVersion) Option {
+	return func(o *options) {
+		o.version = version
+	}
+}
+
+// WithValidation is an Option validating the requests with their Validate
+// method before sending them, failing with the violations found instead.
+func WithValidation() Option {
+	return func(o *options) {
+		o.validate = true
+	}
+}
+
 // Client is soap client
 type Client struct {
 	url         string
@@ -421,13 +461,20 @@ func (s *Client) SetHeaders(headers ...interface{}) {
 
 // CallContext performs HTTP POST request with a context
 func (s *Client) CallContext(ctx context.Context, soapAction string, request, response interface{}) error {
-	return s.call(ctx, soapAction, nil, request, response, nil, nil, nil)
+	return s.call(ctx, s.opts.version, soapAction, nil, request, response, nil, nil, nil)
+}
+
+// CallContextWithVersion performs HTTP POST request with a context, speaking
+// the SOAP protocol version instead of the one set by WithSOAPVersion. The
+// services bound with SOAP 1.2 call it, leaving the client untouched.
+func (s *Client) CallContextWithVersion(ctx context.Context, version SOAPVersion, soapAction string, request, response interface{}) error {
+	return s.call(ctx, version, soapAction, nil, request, response, nil, nil, nil)
 }
 
 // Call performs HTTP POST request.
 // Note that if the server returns a status code >= 400, a HTTPError will be returned
 func (s *Client) Call(soapAction string, request, response interface{}) error {
-	return s.call(context.Background(), soapAction, nil, request, response, nil, nil, nil)
+	return s.call(context.Background(), s.opts.version, soapAction, nil, request, response, nil, nil, nil)
 }
 
 // CallContextWithAttachmentsAndFaultDetail performs HTTP POST request.
@@ -435,13 +482,13 @@ func (s *Client) Call(soapAction string, request, response interface{}) error {
 // On top the attachments array will be filled with attachments returned from the SOAP request.
 func (s *Client) CallContextWithAttachmentsAndFaultDetail(ctx context.Context, soapAction string, request,
 	response interface{}, faultDetail FaultError, attachments *[]MIMEMultipartAttachment) error {
-	return s.call(ctx, soapAction, nil, request, response, nil, faultDetail, attachments)
+	return s.call(ctx, s.opts.version, soapAction, nil, request, response, nil, faultDetail, attachments)
 }
 
 // CallContextWithFault performs HTTP POST request.
 // Note that if SOAP fault is returned, it will be stored in the error.
 func (s *Client) CallContextWithFaultDetail(ctx context.Context, soapAction string, request, response interface{}, faultDetail FaultError) error {
-	return s.call(ctx, soapAction, nil, request, response, nil, faultDetail, nil)
+	return s.call(ctx, s.opts.version, soapAction, nil, request, response, nil, faultDetail, nil)
 }
 
 // CallWithFaultDetail performs HTTP POST request.
@@ -449,20 +496,25 @@ func (s *Client) CallContextWithFaultDetail(ctx context.Context, soapAction stri
 // the passed in fault detail is expected to implement FaultError interface,
 // which allows to condense the detail into a short error message.
 func (s *Client) CallWithFaultDetail(soapAction string, request, response interface{}, faultDetail FaultError) error {
-	return s.call(context.Background(), soapAction, nil, request, response, nil, faultDetail, nil)
+	return s.call(context.Background(), s.opts.version, soapAction, nil, request, response, nil, faultDetail, nil)
 }
 
 func (s *Client) CallWithEnvelope(ctx context.Context, soapAction string, requestEnvelope interface{}, responseEnvelope SOAPResponseEnvelopeInterface, faultDetail FaultError,
 	retAttachments *[]MIMEMultipartAttachment) error {
-	return s.call(ctx, soapAction, requestEnvelope, nil, nil, responseEnvelope, faultDetail, retAttachments)
+	return s.call(ctx, s.opts.version, soapAction, requestEnvelope, nil, nil, responseEnvelope, faultDetail, retAttachments)
 }
 
-func (s *Client) call(ctx context.Context, soapAction string, requestEnvelope, request, response interface{}, responseEnvelope SOAPResponseEnvelopeInterface, faultDetail FaultError,
+func (s *Client) call(ctx context.Context, version SOAPVersion, soapAction string, requestEnvelope, request, response interface{}, responseEnvelope SOAPResponseEnvelopeInterface, faultDetail FaultError,
 	retAttachments *[]MIMEMultipartAttachment) error {
+	if s.opts.validate && request != nil {
+		if err := validateValue(reflect.ValueOf(request)); err != nil {
+			return err
+		}
+	}
 	if requestEnvelope == nil {
 		// SOAP envelope capable of namespace prefixes
 		soapEnvelope := SOAPEnvelope{
-			XmlNS: XmlNsSoapEnv,
+			XmlNS: version.envelopeNamespace(),
 		}
 		soapEnvelope.Headers = s.headers
 		soapEnvelope.Body.Content = request
@@ -505,9 +557,12 @@ func (s *Client) call(ctx context.Context, soapAction string, requestEnvelope, r
 	} else if s.opts.mma {
 		req.Header.Add("Content-Type", fmt.Sprintf(mmaContentType, encoder.(*mmaEncoder).Boundary()))
 	} else {
-		req.Header.Add("Content-Type", "text/xml; charset=\"utf-8\"")
+		req.Header.Add("Content-Type", version.contentType(soapAction))
+	}
+	if version != SOAP12 || s.opts.mtom || s.opts.mma {
+		// Plain SOAP 1.2 requests carry the action in the Content-Type instead.
+		req.Header.Add("SOAPAction", soapAction)
 	}
-	req.Header.Add("SOAPAction", soapAction)
 	req.Header.Set("User-Agent", "gowsdl/0.1")
 	if s.opts.httpHeaders != nil {
 		for k, v := range s.opts.httpHeaders {
@@ -554,14 +609,17 @@ func (s *Client) call(ctx context.Context, soapAction string, requestEnvelope, r
 		// 		Detail: faultDetail,
 		// 	},
 		// }
-		responseEnvelope = &SOAPEnvelopeResponse{
-			Body: &SOAPBodyResponse{
-				Content: response,
-				Fault: &SOAPFault{
-					Detail: faultDetail,
-				},
+		body := &SOAPBodyResponse{
+			Content: response,
+			Fault: &SOAPFault{
+				Detail: faultDetail,
 			},
 		}
+		if version == SOAP12 {
+			responseEnvelope = &SOAP12EnvelopeResponse{Body: body}
+		} else {
+			responseEnvelope = &SOAPEnvelopeResponse{Body: body}
+		}
 
 	}
 
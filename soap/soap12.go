@@ -0,0 +1,138 @@
+package soap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// SOAPVersion identifies the SOAP protocol version spoken by a Client.
+type SOAPVersion int
+
+const (
+	// SOAP11 is SOAP 1.1, using text/xml requests and the SOAPAction header.
+	SOAP11 SOAPVersion = iota
+	// SOAP12 is SOAP 1.2, using application/soap+xml requests.
+	SOAP12
+)
+
+func (v SOAPVersion) String() string {
+	if v == SOAP12 {
+		return "1.2"
+	}
+	return "1.1"
+}
+
+func (v SOAPVersion) envelopeNamespace() string {
+	if v == SOAP12 {
+		return XmlNsSoap12Env
+	}
+	return XmlNsSoapEnv
+}
+
+func (v SOAPVersion) contentType(soapAction string) string {
+	if v != SOAP12 {
+		return `text/xml; charset="utf-8"`
+	}
+	if soapAction == "" {
+		return `application/soap+xml; charset="utf-8"`
+	}
+	return fmt.Sprintf(`application/soap+xml; charset="utf-8"; action="%s"`, soapAction)
+}
+
+// SOAP12EnvelopeResponse is the default response envelope used by a Client
+// configured with WithSOAPVersion(SOAP12).
+type SOAP12EnvelopeResponse struct {
+	XMLName     xml.Name `xml:"http://www.w3.org/2003/05/soap-envelope Envelope"`
+	Header      *SOAPHeaderResponse
+	Body        SoapResponseBodyInterface
+	Attachments []MIMEMultipartAttachment `xml:"attachments,omitempty"`
+}
+
+func (s *SOAP12EnvelopeResponse) GetBody() SoapResponseBodyInterface {
+	return s.Body
+}
+
+func (s *SOAP12EnvelopeResponse) GetHeader() interface{} {
+	return s.Header
+}
+
+func (s *SOAP12EnvelopeResponse) SetBody(body SoapResponseBodyInterface) {
+	s.Body = body
+}
+
+func (s *SOAP12EnvelopeResponse) SetHeader(header interface{}) {
+	s.Header = header.(*SOAPHeaderResponse)
+}
+
+func (s *SOAP12EnvelopeResponse) SetXMLName(xmlName xml.Name) {
+	s.XMLName = xmlName
+}
+
+func (s *SOAP12EnvelopeResponse) GetAttachments() []MIMEMultipartAttachment {
+	return s.Attachments
+}
+
+// SOAP12Fault is a SOAP 1.2 fault. It is returned as the error of a call
+// whose response body holds an env:Fault element.
+type SOAP12Fault struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2003/05/soap-envelope Fault"`
+
+	Code   SOAP12FaultCode   `xml:"http://www.w3.org/2003/05/soap-envelope Code"`
+	Reason SOAP12FaultReason `xml:"http://www.w3.org/2003/05/soap-envelope Reason"`
+	Node   string            `xml:"http://www.w3.org/2003/05/soap-envelope Node,omitempty"`
+	Role   string            `xml:"http://www.w3.org/2003/05/soap-envelope Role,omitempty"`
+	Detail FaultError        `xml:"http://www.w3.org/2003/05/soap-envelope Detail,omitempty"`
+}
+
+// SOAP12FaultCode is the env:Code of a SOAP 1.2 fault, with its optional
+// chain of more specific subcodes.
+type SOAP12FaultCode struct {
+	Value   string           `xml:"http://www.w3.org/2003/05/soap-envelope Value"`
+	Subcode *SOAP12FaultCode `xml:"http://www.w3.org/2003/05/soap-envelope Subcode,omitempty"`
+}
+
+// Subcodes returns the values of all nested subcodes, outermost first.
+func (c SOAP12FaultCode) Subcodes() []string {
+	var codes []string
+	for sc := c.Subcode; sc != nil; sc = sc.Subcode {
+		codes = append(codes, sc.Value)
+	}
+	return codes
+}
+
+// SOAP12FaultReason holds the human readable explanations of a SOAP 1.2
+// fault, one per language.
+type SOAP12FaultReason struct {
+	Text []SOAP12FaultText `xml:"http://www.w3.org/2003/05/soap-envelope Text"`
+}
+
+// SOAP12FaultText is a single env:Text of a SOAP 1.2 fault reason.
+type SOAP12FaultText struct {
+	Lang  string `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+// String returns the first reason text, preferring English when several
+// languages are present.
+func (r SOAP12FaultReason) String() string {
+	for _, t := range r.Text {
+		if strings.HasPrefix(strings.ToLower(t.Lang), "en") {
+			return t.Value
+		}
+	}
+	if len(r.Text) > 0 {
+		return r.Text[0].Value
+	}
+	return ""
+}
+
+func (f *SOAP12Fault) Error() string {
+	if f.Detail != nil && f.Detail.HasData() {
+		return f.Detail.ErrorString()
+	}
+	if reason := f.Reason.String(); reason != "" {
+		return reason
+	}
+	return f.Code.Value
+}
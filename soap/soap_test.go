@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -145,6 +146,102 @@ func TestClient_Call(t *testing.T) {
 	}
 }
 
+func TestClient_CallSOAP12(t *testing.T) {
+	var gotContentType, gotSOAPAction, gotEnvelopeNS string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		gotSOAPAction = r.Header.Get("SOAPAction")
+		var soapRequest TestSoapRequest
+		err := xml.NewDecoder(r.Body).Decode(&soapRequest)
+		assert.NoError(t, err)
+		gotEnvelopeNS = soapRequest.XMLName.Space
+		rsp := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+		<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+			<env:Body>
+				<PingResponse xmlns="http://example.com/service.xsd">
+					<PingResult>
+						<Message>Pong %s</Message>
+					</PingResult>
+				</PingResponse>
+			</env:Body>
+		</env:Envelope>`, soapRequest.Body.PingRequest.Request.Message)
+		w.Write([]byte(rsp))
+	}))
+	defer ts.Close()
+
+	// The generated SOAP 1.2 services pass the version on every call.
+	calls := []func(req, reply interface{}) error{
+		func(req, reply interface{}) error {
+			return NewClient(ts.URL, WithSOAPVersion(SOAP12)).Call("urn:GetData", req, reply)
+		},
+		func(req, reply interface{}) error {
+			return NewClient(ts.URL).CallContextWithVersion(context.Background(), SOAP12, "urn:GetData", req, reply)
+		},
+	}
+	for _, call := range calls {
+		req := &Ping{Request: &PingRequest{Message: "Ada"}}
+		reply := &PingResponse{}
+		if err := call(req, reply); err != nil {
+			t.Fatalf("couln't call service: %v", err)
+		}
+
+		assert.Equal(t, `application/soap+xml; charset="utf-8"; action="urn:GetData"`, gotContentType)
+		assert.Empty(t, gotSOAPAction)
+		assert.Equal(t, XmlNsSoap12Env, gotEnvelopeNS)
+		assert.Equal(t, "Pong Ada", reply.PingResult.Message)
+	}
+}
+
+func TestClient_SOAP12Fault(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+		<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:m="http://example.com/timeouts">
+			<env:Body>
+				<env:Fault>
+					<env:Code>
+						<env:Value>env:Sender</env:Value>
+						<env:Subcode>
+							<env:Value>m:MessageTimeout</env:Value>
+						</env:Subcode>
+					</env:Code>
+					<env:Reason>
+						<env:Text xml:lang="nl">Verlopen bericht</env:Text>
+						<env:Text xml:lang="en">Message timed out</env:Text>
+					</env:Reason>
+					<env:Detail>
+						<SimpleNode>
+							<Detail>detail message</Detail>
+							<Num>1.5</Num>
+						</SimpleNode>
+					</env:Detail>
+				</env:Fault>
+			</env:Body>
+		</env:Envelope>`))
+	}))
+	defer ts.Close()
+
+	t.Run("WithoutDetail", func(t *testing.T) {
+		client := NewClient(ts.URL, WithSOAPVersion(SOAP12))
+		err := client.Call("urn:GetData", &Ping{}, &PingResponse{})
+		fault, ok := err.(*SOAP12Fault)
+		if !ok {
+			t.Fatalf("expected a *SOAP12Fault, got %T: %v", err, err)
+		}
+		assert.Equal(t, "env:Sender", fault.Code.Value)
+		assert.Equal(t, []string{"m:MessageTimeout"}, fault.Code.Subcodes())
+		assert.EqualError(t, err, "Message timed out")
+	})
+
+	t.Run("WithDetail", func(t *testing.T) {
+		client := NewClient(ts.URL, WithSOAPVersion(SOAP12))
+		detail := Wrapper{Item: &SimpleNode{}, hasData: true}
+		err := client.CallWithFaultDetail("urn:GetData", &Ping{}, &PingResponse{}, &detail)
+		assert.EqualError(t, err, "1.50: detail message")
+		assert.EqualValues(t, &SimpleNode{Detail: "detail message", Num: 1.5}, detail.Item)
+	})
+}
+
 func TestClient_CallEnvelope(t *testing.T) {
 
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -628,6 +725,297 @@ func Test_Client_FaultDefault(t *testing.T) {
 	}
 }
 
+type rpcAdd struct {
+	A int32
+	B *int32
+}
+
+func (r rpcAdd) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return EncodeRPC(e, xml.Name{Space: "urn:calculator", Local: "add"}, "",
+		RPCPart{Name: "a", Value: r.A},
+		RPCPart{Name: "b", Value: r.B},
+	)
+}
+
+type rpcLines []string
+
+func (a rpcLines) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return MarshalArray(e, start, xml.Name{Space: XmlNsXSD, Local: "string"}, []string(a))
+}
+
+func (a *rpcLines) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return UnmarshalArray(d, start, (*[]string)(a))
+}
+
+type rpcDescribe struct {
+	Lines rpcLines `xml:"lines"`
+}
+
+func (r rpcDescribe) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return EncodeRPC(e, xml.Name{Space: "urn:machines", Local: "describe"}, XmlNsSoapEnc,
+		RPCPart{Name: "lines", Type: xml.Name{Space: "urn:machines", Local: "ArrayOfString"}, Value: r.Lines},
+	)
+}
+
+func TestEncodeRPC(t *testing.T) {
+	data, err := xml.Marshal(rpcAdd{A: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, `<ns1:add xmlns:ns1="urn:calculator"><a>1</a></ns1:add>`, string(data))
+
+	data, err = xml.Marshal(rpcDescribe{Lines: rpcLines{"a", "b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, `<ns1:describe xmlns:ns1="urn:machines" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" soap:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">`+
+		`<lines xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:soapenc="http://schemas.xmlsoap.org/soap/encoding/" xmlns:xsd="http://www.w3.org/2001/XMLSchema" xsi:type="ns1:ArrayOfString" soapenc:arrayType="xsd:string[2]">`+
+		`<item>a</item><item>b</item></lines></ns1:describe>`, string(data))
+}
+
+func TestUnmarshalArray(t *testing.T) {
+	var r rpcDescribe
+	err := xml.Unmarshal([]byte(`<describeResponse>
+		<lines xsi:type="soapenc:Array" soapenc:arrayType="xsd:string[2]">
+			<item xsi:type="xsd:string">a</item>
+			<string>b</string>
+		</lines>
+	</describeResponse>`), &r)
+	assert.NoError(t, err)
+	assert.Equal(t, rpcLines{"a", "b"}, r.Lines)
+}
+
+type shape interface{ isShape() }
+
+type circle struct {
+	Radius int `xml:"radius"`
+}
+
+type square struct {
+	Side int `xml:"side"`
+}
+
+func (*circle) isShape() {}
+func (*square) isShape() {}
+
+var shapeSubstitutes = SubstitutionGroup{
+	{Name: xml.Name{Space: "urn:shapes", Local: "circle"}, Type: xml.Name{Space: "urn:shapes", Local: "Circle"}, New: func() interface{} { return new(circle) }},
+	{Name: xml.Name{Space: "urn:shapes", Local: "square"}, Type: xml.Name{Space: "urn:shapes", Local: "Square"}, New: func() interface{} { return new(square) }},
+}
+
+type shapeGroup []shape
+
+func (g shapeGroup) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	for _, v := range g {
+		if err := shapeSubstitutes.Encode(e, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *shapeGroup) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	v, err := shapeSubstitutes.Decode(d, start)
+	if v != nil {
+		*g = append(*g, v.(shape))
+	}
+	return err
+}
+
+type drawing struct {
+	XMLName xml.Name   `xml:"urn:shapes drawing"`
+	Title   string     `xml:"urn:shapes title"`
+	Shapes  shapeGroup `xml:",any,omitempty"`
+}
+
+func TestSubstitutionGroup(t *testing.T) {
+	var d drawing
+	err := xml.Unmarshal([]byte(`<drawing xmlns="urn:shapes" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+		<title>t</title>
+		<square><side>2</side></square>
+		<unknown/>
+		<circle><radius>1</radius></circle>
+		<shape xmlns:s="urn:shapes" xsi:type="s:Circle"><radius>3</radius></shape>
+	</drawing>`), &d)
+	assert.NoError(t, err)
+	assert.Equal(t, "t", d.Title)
+	assert.Equal(t, shapeGroup{&square{Side: 2}, &circle{Radius: 1}, &circle{Radius: 3}}, d.Shapes)
+
+	data, err := xml.Marshal(drawing{Title: "t", Shapes: shapeGroup{&circle{Radius: 1}, nil, &square{Side: 2}}})
+	assert.NoError(t, err)
+	assert.Equal(t, `<drawing xmlns="urn:shapes"><title xmlns="urn:shapes">t</title>`+
+		`<circle xmlns="urn:shapes"><radius>1</radius></circle><square xmlns="urn:shapes"><side>2</side></square></drawing>`, string(data))
+}
+
+type animal interface {
+	isAnimal()
+}
+
+type Pet struct {
+	Name string `xml:"name"`
+}
+
+type Dog struct {
+	XMLName xml.Name `xml:"urn:pets favorite"`
+	*Pet
+	Breed string `xml:"breed"`
+}
+
+func (*Pet) isAnimal() {}
+
+var petHierarchy = TypeHierarchy{
+	{Type: xml.Name{Space: "urn:pets", Local: "Pet"}, New: func() interface{} { return new(Pet) }},
+	{Type: xml.Name{Space: "urn:pets", Local: "Dog"}, New: func() interface{} { return new(Dog) }},
+}
+
+type petValue struct {
+	Value animal
+}
+
+func (v petValue) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return petHierarchy.Encode(e, start, v.Value)
+}
+
+func (v *petValue) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	x, err := petHierarchy.Decode(d, start)
+	if x != nil {
+		v.Value = x.(animal)
+	}
+	return err
+}
+
+type shelter struct {
+	XMLName xml.Name    `xml:"urn:pets shelter"`
+	Pets    []*petValue `xml:"urn:pets pet"`
+}
+
+func TestTypeHierarchy(t *testing.T) {
+	var s shelter
+	err := xml.Unmarshal([]byte(`<shelter xmlns="urn:pets" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+		<pet><name>a</name></pet>
+		<pet xmlns:p="urn:pets" xsi:type="p:Dog"><name>b</name><breed>lab</breed></pet>
+		<pet xsi:type="Dog"><name>c</name></pet>
+		<pet xsi:type="Cat"><name>d</name></pet>
+	</shelter>`), &s)
+	assert.NoError(t, err)
+	if assert.Len(t, s.Pets, 4) {
+		assert.Equal(t, &Pet{Name: "a"}, s.Pets[0].Value)
+		// The element is decoded whatever the name Dog expects.
+		assert.Equal(t, &Dog{XMLName: xml.Name{Space: "urn:pets", Local: "favorite"}, Pet: &Pet{Name: "b"}, Breed: "lab"}, s.Pets[1].Value)
+		assert.IsType(t, &Dog{}, s.Pets[2].Value)
+		assert.Equal(t, &Pet{Name: "d"}, s.Pets[3].Value)
+	}
+
+	data, err := xml.Marshal(shelter{Pets: []*petValue{{Value: &Pet{Name: "a"}}, {Value: &Dog{Pet: &Pet{Name: "b"}}}, {}}})
+	assert.NoError(t, err)
+	assert.Equal(t, `<shelter xmlns="urn:pets"><pet xmlns="urn:pets"><name>a</name></pet>`+
+		`<pet xmlns="urn:pets" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:ns1="urn:pets" xsi:type="ns1:Dog"><name>b</name><breed></breed></pet></shelter>`, string(data))
+
+	err = petHierarchy.Encode(xml.NewEncoder(io.Discard), xml.StartElement{Name: xml.Name{Local: "pet"}}, &circle{})
+	assert.Error(t, err)
+}
+
+type code string
+
+func TestFacets(t *testing.T) {
+	tests := []struct {
+		facets Facets
+		value  interface{}
+		valid  bool
+	}{
+		{Facets{Enumeration: []string{"a", "b"}}, code("b"), true},
+		{Facets{Enumeration: []string{"a", "b"}}, code("c"), false},
+		{Facets{Enumeration: []string{"1", "2"}}, int32(2), true},
+		{Facets{Pattern: `^(?:[A-Z]{2})$`}, "AB", true},
+		{Facets{Pattern: `^(?:[A-Z]{2})$`}, "ABC", false},
+		{Facets{MinInclusive: "10", MaxInclusive: "100"}, 9.5, false},
+		{Facets{MinInclusive: "10", MaxInclusive: "100"}, 100, true},
+		{Facets{MaxInclusive: "100"}, uint8(101), false},
+		{Facets{MaxInclusive: "2020-12-31"}, "2021-01-01", false},
+		{Facets{Length: 2}, "é!", true},
+		{Facets{MinLength: 2}, []string{"a"}, false},
+		{Facets{MaxLength: 2}, []byte("abc"), false},
+		{Facets{MaxLength: 2}, struct{}{}, true},
+	}
+	for _, test := range tests {
+		err := test.facets.Validate(test.value)
+		if test.valid {
+			assert.NoError(t, err, "%+v %v", test.facets, test.value)
+		} else {
+			assert.IsType(t, &ValidationError{}, err, "%+v %v", test.facets, test.value)
+		}
+	}
+
+	err := Facets{MinLength: 2}.ValidateField("Name", "a")
+	assert.EqualError(t, err, "Name: length 1 is less than 2")
+	assert.NoError(t, Facets{MinLength: 2}.ValidateField("Name", ""))
+}
+
+// booking and passenger validate like the generated types.
+type booking struct {
+	Code       bookingCode
+	Passengers []*passenger
+}
+
+func (b *booking) Validate() error {
+	return Validate(
+		Required("Code", b.Code),
+		Field("Code", b.Code),
+		Occurs("Passengers", len(b.Passengers), 1, 2),
+		Field("Passengers", b.Passengers),
+	)
+}
+
+type bookingCode string
+
+func (c bookingCode) Validate() error {
+	return Facets{MaxLength: 3}.Validate(c)
+}
+
+type passenger struct {
+	Name, Phone, Email string
+}
+
+func (p *passenger) Validate() error {
+	return Validate(
+		Required("Name", p.Name),
+		Choice([]string{"Phone", "Email"}, p.Phone, p.Email),
+	)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(nil, nil))
+
+	b := &booking{Code: "ABCD", Passengers: []*passenger{{Name: "Ann", Phone: "1"}, {Phone: "1", Email: "a@b"}}}
+	assert.EqualError(t, b.Validate(), "Code: length 4 is greater than 3; Passengers[1].Name: is required; "+
+		"Passengers[1].Email: only one of Phone, Email may be set")
+	assert.EqualError(t, (&booking{}).Validate(), "Code: is required; Passengers: occurs 0 times, less than 1")
+
+	// Values validate through their pointers, and nil ones are valid.
+	var errs ValidationErrors
+	assert.True(t, errors.As(Field("Booking", *b), &errs))
+	assert.Len(t, errs, 3)
+	assert.Equal(t, "Booking.Passengers[1].Name", errs[1].Path)
+	assert.NoError(t, Field("Booking", (*booking)(nil)))
+
+	// Anonymous structs are validated in place, optional ones unless absent.
+	items := []passenger{{Name: "Ann"}, {}}
+	assert.EqualError(t, Each("Items", len(items), func(i int) error { return items[i].Validate() }), "Items[1].Name: is required")
+	var item passenger
+	assert.NoError(t, Optional("Item", item, item.Validate))
+	item.Phone = "1"
+	assert.EqualError(t, Optional("Item", item, item.Validate), "Item.Name: is required")
+}
+
+func TestClient_CallWithValidation(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, WithValidation())
+	err := client.Call("Book", &booking{Code: "ABC"}, &PingResponse{})
+	assert.EqualError(t, err, "Passengers: occurs 0 times, less than 1")
+	assert.Zero(t, calls, "invalid requests should not be sent")
+}
+
 // TestXsdDateTime checks the marshalled xsd datetime
 func TestXsdDateTime(t *testing.T) {
 	type TestDateTime struct {
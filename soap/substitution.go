@@ -0,0 +1,171 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Substitute is an element that may appear in place of the head of its
+// substitution group.
+type Substitute struct {
+	// Name is the name of the element, and Type the name of its schema type,
+	// which the element may announce through xsi:type.
+	Name xml.Name
+	Type xml.Name
+	// New returns a new value of the Go type of the element.
+	New func() interface{}
+}
+
+// SubstitutionGroup lists the substitutes of the head of a substitution
+// group. It is meant to be used by the MarshalXML and UnmarshalXML methods of
+// the generated types holding the elements of the group.
+type SubstitutionGroup []Substitute
+
+// Decode decodes the element start into a new value of the substitute named
+// like it, or else of the one of its xsi:type. Elements that aren't part of
+// the group are skipped, returning nil.
+func (g SubstitutionGroup) Decode(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	s := g.lookup(start)
+	if s == nil {
+		return nil, d.Skip()
+	}
+
+	return decodeAs(d, start, s.New())
+}
+
+func (g SubstitutionGroup) lookup(start xml.StartElement) *Substitute {
+	for i := range g {
+		if g[i].Name == start.Name {
+			return &g[i]
+		}
+	}
+
+	typ, ok := xsiType(start)
+	if !ok {
+		return nil
+	}
+	for i := range g {
+		if g[i].Type.Local == typ.Local && (typ.Space == "" || g[i].Type.Space == typ.Space) {
+			return &g[i]
+		}
+	}
+	return nil
+}
+
+// xsiType returns the xsi:type of start. The namespace of its prefix is only
+// known when declared by start itself, and left empty otherwise.
+func xsiType(start xml.StartElement) (xml.Name, bool) {
+	for _, attr := range start.Attr {
+		if attr.Name.Local != "type" || (attr.Name.Space != XmlNsXSI && attr.Name.Space != "xsi") {
+			continue
+		}
+
+		prefix, local := "", attr.Value
+		if i := strings.Index(local, ":"); i >= 0 {
+			prefix, local = local[:i], local[i+1:]
+		}
+		name := xml.Name{Local: local}
+		for _, ns := range start.Attr {
+			if ns.Name.Space == "xmlns" && ns.Name.Local == prefix || prefix == "" && ns.Name.Space == "" && ns.Name.Local == "xmlns" {
+				name.Space = ns.Value
+			}
+		}
+		return name, true
+	}
+	return xml.Name{}, false
+}
+
+// decodeAs decodes the element start into v, a pointer to a new value. The
+// name of the element is taken for the one v expects, if any, so types named
+// after another element decode the elements announcing them by xsi:type.
+func decodeAs(d *xml.Decoder, start xml.StartElement, v interface{}) (interface{}, error) {
+	if f, ok := reflect.TypeOf(v).Elem().FieldByName("XMLName"); ok && f.Type == reflect.TypeOf(xml.Name{}) {
+		tag := strings.Split(f.Tag.Get("xml"), ",")[0]
+		if i := strings.LastIndex(tag, " "); i >= 0 {
+			start.Name = xml.Name{Space: tag[:i], Local: tag[i+1:]}
+		} else if tag != "" {
+			start.Name.Local = tag
+		}
+	}
+	if err := d.DecodeElement(v, &start); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Encode encodes v as the element of the first substitute of its Go type. Nil
+// values are omitted.
+func (g SubstitutionGroup) Encode(e *xml.Encoder, v interface{}) error {
+	if isNil(v) {
+		return nil
+	}
+
+	typ := reflect.TypeOf(v)
+	for _, s := range g {
+		if reflect.TypeOf(s.New()) == typ {
+			return e.EncodeElement(v, xml.StartElement{Name: s.Name})
+		}
+	}
+	return fmt.Errorf("soap: %T is not a substitute of the group", v)
+}
+
+// Subtype is a type of an extension hierarchy.
+type Subtype struct {
+	// Type is the name of the schema type, announced through xsi:type.
+	Type xml.Name
+	// New returns a new value of the Go type of the schema type.
+	New func() interface{}
+}
+
+// TypeHierarchy lists a complex type, first, and the types derived from it by
+// extension, directly or not. It is meant to be used by the MarshalXML and
+// UnmarshalXML methods of the generated types holding a value of any of them.
+type TypeHierarchy []Subtype
+
+// Decode decodes the element start into a new value of the type of its
+// xsi:type, or of the first type when it has none, or an unknown one.
+func (h TypeHierarchy) Decode(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	if len(h) == 0 {
+		return nil, d.Skip()
+	}
+
+	s := &h[0]
+	if typ, ok := xsiType(start); ok {
+		for i := range h {
+			if h[i].Type.Local == typ.Local && (typ.Space == "" || h[i].Type.Space == typ.Space) {
+				s = &h[i]
+				break
+			}
+		}
+	}
+	return decodeAs(d, start, s.New())
+}
+
+// Encode encodes v as the element start, announcing its type through xsi:type
+// unless it is the first type. Nil values are omitted.
+func (h TypeHierarchy) Encode(e *xml.Encoder, start xml.StartElement, v interface{}) error {
+	if isNil(v) {
+		return nil
+	}
+
+	typ := reflect.TypeOf(v)
+	for i, s := range h {
+		if reflect.TypeOf(s.New()) != typ {
+			continue
+		}
+		if i > 0 {
+			var p prefixes
+			xsiType := p.qualify(xml.Name{Space: XmlNsXSI, Local: "type"})
+			value := p.qualify(s.Type)
+			start.Attr = append(append(start.Attr, p.attrs...), xml.Attr{Name: xml.Name{Local: xsiType}, Value: value})
+		}
+		return e.EncodeElement(v, start)
+	}
+	return fmt.Errorf("soap: %T is not part of the type hierarchy", v)
+}
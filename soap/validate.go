@@ -0,0 +1,376 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"encoding"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ValidationError reports a value violating a constraint of its schema type.
+type ValidationError struct {
+	// Path locates the value within the validated one, e.g. Items[2].Name,
+	// and is empty for the validated value itself.
+	Path   string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Reason
+	}
+	return e.Path + ": " + e.Reason
+}
+
+// ValidationErrors lists the violations found validating a value.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	reasons := make([]string, len(e))
+	for i, err := range e {
+		reasons[i] = err.Error()
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// validator is implemented by the generated types.
+type validator interface {
+	Validate() error
+}
+
+var validatorType = reflect.TypeOf((*validator)(nil)).Elem()
+
+// Validate returns the violations errs report as ValidationErrors, or nil
+// when there are none. It is meant to be used by the Validate methods of the
+// generated types, along with the functions checking their fields.
+func Validate(errs ...error) error {
+	var violations ValidationErrors
+	for _, err := range errs {
+		var list ValidationErrors
+		var single *ValidationError
+		switch {
+		case err == nil:
+		case errors.As(err, &list):
+			violations = append(violations, list...)
+		case errors.As(err, &single):
+			violations = append(violations, single)
+		default:
+			violations = append(violations, &ValidationError{Reason: err.Error()})
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return violations
+}
+
+// Prefix prefixes the paths of the violations err reports by path.
+func Prefix(path string, err error) error {
+	if err == nil || path == "" {
+		return err
+	}
+
+	violations, _ := Validate(err).(ValidationErrors)
+	prefixed := make(ValidationErrors, len(violations))
+	for i, v := range violations {
+		prefixed[i] = &ValidationError{Path: joinPath(path, v.Path), Reason: v.Reason}
+	}
+	return prefixed
+}
+
+func joinPath(path, sub string) string {
+	switch {
+	case sub == "":
+		return path
+	case strings.HasPrefix(sub, "["):
+		return path + sub
+	}
+	return path + "." + sub
+}
+
+// Required reports the value v of the required field at path when it is nil,
+// or an empty string, slice or map. Numbers and booleans, whose zero values
+// can't be told apart from absent ones, aren't reported.
+func Required(path string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Invalid:
+	case reflect.Ptr, reflect.Interface:
+		if !rv.IsNil() {
+			return nil
+		}
+	case reflect.String, reflect.Slice, reflect.Map:
+		if rv.Len() > 0 {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return &ValidationError{Path: path, Reason: "is required"}
+}
+
+// Occurs reports the n occurrences of the element at path when they are
+// fewer than min, or more than max unless it is zero.
+func Occurs(path string, n, min, max int) error {
+	switch {
+	case n < min:
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("occurs %d times, less than %d", n, min)}
+	case max > 0 && n > max:
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("occurs %d times, more than %d", n, max)}
+	}
+	return nil
+}
+
+// Choice reports the second alternative of a choice set when another one is,
+// the values of the alternatives at paths being set unless they are the zero
+// value.
+func Choice(paths []string, values ...interface{}) error {
+	set := 0
+	for i, v := range values {
+		if v == nil || reflect.ValueOf(v).IsZero() {
+			continue
+		}
+		if set++; set > 1 {
+			return &ValidationError{Path: paths[i], Reason: fmt.Sprintf("only one of %s may be set", strings.Join(paths, ", "))}
+		}
+	}
+	return nil
+}
+
+// Field validates the value v of the field at path with its Validate method,
+// if any, and the items of slices at path[i]. Nil values are valid.
+func Field(path string, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	return Prefix(path, validateValue(reflect.ValueOf(v)))
+}
+
+func validateValue(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+	}
+
+	switch {
+	case v.Type().Implements(validatorType):
+		return v.Interface().(validator).Validate()
+	case v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface && reflect.PtrTo(v.Type()).Implements(validatorType):
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		return p.Interface().(validator).Validate()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return validateValue(v.Elem())
+	case reflect.Slice, reflect.Array:
+		var errs []error
+		for i := 0; i < v.Len(); i++ {
+			errs = append(errs, Prefix(fmt.Sprintf("[%d]", i), validateValue(v.Index(i))))
+		}
+		return Validate(errs...)
+	}
+	return nil
+}
+
+// Optional validates the value v of the optional field at path with validate,
+// unless it is the zero value, which absent elements of anonymous types have.
+func Optional(path string, v interface{}, validate func() error) error {
+	if v == nil || reflect.ValueOf(v).IsZero() {
+		return nil
+	}
+	return Prefix(path, validate())
+}
+
+// Each validates the n items of the field at path with validate, reporting
+// their violations at path[i]. It is meant for slices of anonymous structs.
+func Each(path string, n int, validate func(i int) error) error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = Prefix(fmt.Sprintf("%s[%d]", path, i), validate(i))
+	}
+	return Validate(errs...)
+}
+
+// Facets are the constraining facets of a simple type. Zero facets don't
+// constrain.
+type Facets struct {
+	Enumeration []string
+	// Pattern is a regular expression the whole value must match, in the
+	// syntax of the regexp package.
+	Pattern string
+	// MinInclusive and MaxInclusive bound numbers numerically, and other
+	// values in lexical order.
+	MinInclusive string
+	MaxInclusive string
+	// Length, MinLength and MaxLength count the characters of strings, the
+	// octets of binary values and the items of lists.
+	Length    int
+	MinLength int
+	MaxLength int
+}
+
+var patterns sync.Map
+
+// Validate returns a *ValidationError when v violates a facet of f. The
+// values whose lexical form isn't known, such as structs, aren't checked.
+func (f Facets) Validate(v interface{}) error {
+	if err := f.validate(v); err != "" {
+		return &ValidationError{Reason: err}
+	}
+	return nil
+}
+
+// ValidateField validates the value v of the optional field at path like
+// Validate, unless it is the zero value, which absent fields have. Required
+// fields are validated whatever their value.
+func (f Facets) ValidateField(path string, v interface{}) error {
+	if v == nil || reflect.ValueOf(v).IsZero() {
+		return nil
+	}
+	if err := f.validate(v); err != "" {
+		return &ValidationError{Path: path, Reason: err}
+	}
+	return nil
+}
+
+func (f Facets) validate(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+
+	if f.Length > 0 || f.MinLength > 0 || f.MaxLength > 0 {
+		if n, ok := length(rv); ok {
+			switch {
+			case f.Length > 0 && n != f.Length:
+				return fmt.Sprintf("length %d is not %d", n, f.Length)
+			case f.MinLength > 0 && n < f.MinLength:
+				return fmt.Sprintf("length %d is less than %d", n, f.MinLength)
+			case f.MaxLength > 0 && n > f.MaxLength:
+				return fmt.Sprintf("length %d is greater than %d", n, f.MaxLength)
+			}
+		}
+	}
+
+	text, ok := lexical(rv)
+	if !ok {
+		return ""
+	}
+	if len(f.Enumeration) > 0 {
+		enumerated := false
+		for _, value := range f.Enumeration {
+			if text == value {
+				enumerated = true
+				break
+			}
+		}
+		if !enumerated {
+			return fmt.Sprintf("value %q is not one of %q", text, f.Enumeration)
+		}
+	}
+	if f.Pattern != "" {
+		re, err := pattern(f.Pattern)
+		if err != nil {
+			return err.Error()
+		}
+		if !re.MatchString(text) {
+			return fmt.Sprintf("value %q does not match pattern %s", text, f.Pattern)
+		}
+	}
+	if f.MinInclusive != "" && compare(text, f.MinInclusive) < 0 {
+		return fmt.Sprintf("value %s is less than %s", text, f.MinInclusive)
+	}
+	if f.MaxInclusive != "" && compare(text, f.MaxInclusive) > 0 {
+		return fmt.Sprintf("value %s is greater than %s", text, f.MaxInclusive)
+	}
+	return ""
+}
+
+func pattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(expr, re)
+	return re, nil
+}
+
+// length returns the length of a string, binary value or list.
+func length(v reflect.Value) (int, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), true
+	case reflect.Slice, reflect.Array:
+		return v.Len(), true
+	}
+	return 0, false
+}
+
+// lexical returns the lexical form of a simple value.
+func lexical(v reflect.Value) (string, bool) {
+	if v.CanInterface() {
+		switch m := v.Interface().(type) {
+		case xml.MarshalerAttr:
+			attr, err := m.MarshalXMLAttr(xml.Name{})
+			return attr.Value, err == nil
+		case encoding.TextMarshaler:
+			text, err := m.MarshalText()
+			return string(text), err == nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), true
+	}
+	return "", false
+}
+
+// compare compares a and b as numbers when both are, and as strings
+// otherwise.
+func compare(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	switch {
+	case errA != nil || errB != nil:
+		if a < b {
+			return -1
+		} else if a > b {
+			return 1
+		}
+		return 0
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
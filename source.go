@@ -0,0 +1,123 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Position is a position within a WSDL or XSD document. Line and Column are
+// 1-based, and zero when unknown.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String returns the position as file:line:column, leaving out the unknown
+// parts.
+func (p Position) String() string {
+	s := p.File
+	if p.Line > 0 {
+		s += fmt.Sprintf(":%d", p.Line)
+		if p.Column > 0 {
+			s += fmt.Sprintf(":%d", p.Column)
+		}
+	}
+	return s
+}
+
+// UnmarshalXMLAttr implements interface xml.UnmarshalerAttr for Position,
+// reading the position attribute decodeSource adds to start tags.
+func (p *Position) UnmarshalXMLAttr(attr xml.Attr) error {
+	parts := strings.SplitN(attr.Value, ":", 3)
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid position %q", attr.Value)
+	}
+	line, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return fmt.Errorf("invalid position %q: %w", attr.Value, err)
+	}
+	column, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return fmt.Errorf("invalid position %q: %w", attr.Value, err)
+	}
+	*p = Position{File: parts[2], Line: line, Column: column}
+	return nil
+}
+
+// positionAttr is the attribute decodeSource adds to the start tags of a
+// document, holding their position as line:column:file.
+var positionAttr = xml.Name{Space: "urn:gowsdl:source", Local: "position"}
+
+// Declaration is embedded by the WSDL and XSD elements to record the position
+// of their declaration, which is known when the document is decoded with
+// decodeSource.
+type Declaration struct {
+	// Pos is the position of the declaration.
+	Pos Position `xml:"urn:gowsdl:source position,attr"`
+}
+
+// sourceFile maps the offsets of a document to positions.
+type sourceFile struct {
+	name string
+	data []byte
+	// lines holds the offset of the start of each line.
+	lines []int
+}
+
+func newSourceFile(name string, data []byte) *sourceFile {
+	lines := []int{0}
+	for i, b := range data {
+		if b == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return &sourceFile{name: name, data: data, lines: lines}
+}
+
+// position returns the position of the start tag ending before offset. '<'
+// can't appear within a start tag, so the tag starts at the last one.
+func (f *sourceFile) position(offset int64) Position {
+	start := int(offset)
+	if start > len(f.data) {
+		start = len(f.data)
+	}
+	if i := bytes.LastIndexByte(f.data[:start], '<'); i >= 0 {
+		start = i
+	}
+
+	line := sort.Search(len(f.lines), func(i int) bool { return f.lines[i] > start }) - 1
+	return Position{File: f.name, Line: line + 1, Column: start - f.lines[line] + 1}
+}
+
+// sourceReader reads the tokens of a document, adding their position to the
+// start tags as the positionAttr attribute.
+type sourceReader struct {
+	d    *xml.Decoder
+	file *sourceFile
+}
+
+func (r *sourceReader) Token() (xml.Token, error) {
+	tok, err := r.d.RawToken()
+	if start, ok := tok.(xml.StartElement); ok {
+		pos := r.file.position(r.d.InputOffset())
+		start.Attr = append(start.Attr, xml.Attr{Name: positionAttr, Value: fmt.Sprintf("%d:%d:%s", pos.Line, pos.Column, pos.File)})
+		tok = start
+	}
+	return tok, err
+}
+
+// decodeSource decodes the document data read from name into v, recording the
+// positions of the model elements.
+func decodeSource(name string, data []byte, v interface{}) error {
+	r := &sourceReader{d: xml.NewDecoder(bytes.NewReader(data)), file: newSourceFile(name, data)}
+	return xml.NewTokenDecoder(r).Decode(v)
+}
@@ -0,0 +1,127 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// substitution is a concrete element of the substitution group of an
+// abstract element.
+type substitution struct {
+	name xml.Name
+	// typ is the type of the element, empty when anonymous or inherited
+	// from the head of the group.
+	typ xml.Name
+}
+
+// collectSubstitutions returns the concrete elements of the substitution
+// groups of the abstract elements of schemas, keyed by abstract element, in
+// declaration order. Elements substituting an abstract element through other
+// abstract elements are part of its group too. Abstract elements nothing
+// substitutes are left out.
+func collectSubstitutions(schemas []*XSDSchema) map[xml.Name][]substitution {
+	elements := make(map[xml.Name]*XSDElement)
+	declaring := make(map[xml.Name]*XSDSchema)
+	heads := make(map[xml.Name][]xml.Name)
+	var names []xml.Name
+	for _, schema := range schemas {
+		for _, elm := range schema.Elements {
+			name := xml.Name{Space: schema.TargetNamespace, Local: elm.Name}
+			if _, ok := elements[name]; ok {
+				continue
+			}
+			elements[name], declaring[name] = elm, schema
+			names = append(names, name)
+			if elm.SubstitutionGroup != "" {
+				head := schema.qname(elm.SubstitutionGroup)
+				heads[head] = append(heads[head], name)
+			}
+		}
+	}
+
+	substitutions := make(map[xml.Name][]substitution)
+	for _, name := range names {
+		if !elements[name].Abstract {
+			continue
+		}
+
+		var group []substitution
+		seen := map[xml.Name]bool{name: true}
+		var walk func(head xml.Name)
+		walk = func(head xml.Name) {
+			for _, member := range heads[head] {
+				if seen[member] {
+					continue
+				}
+				seen[member] = true
+				if elm := elements[member]; !elm.Abstract {
+					s := substitution{name: member}
+					if elm.Type != "" {
+						s.typ = declaring[member].qname(elm.Type)
+					}
+					group = append(group, s)
+				}
+				walk(member)
+			}
+		}
+		walk(name)
+		if len(group) > 0 {
+			substitutions[name] = group
+		}
+	}
+	return substitutions
+}
+
+// substitutes returns the elements substituting the abstract element head
+// whose Go types are declared in the package of its interface, and may thus
+// implement it. Elements sharing a Go type are only listed once. Elements of
+// built-in types can't implement the interface and are left out.
+func (g *GoWSDL) substitutes(head xml.Name) []*Substitute {
+	headType, _, ok := g.symbols.lookup(elementSymbol, head)
+	if !ok {
+		return nil
+	}
+
+	var substitutes []*Substitute
+	goTypes := make(map[string]bool)
+	for _, s := range g.substitutions[head] {
+		goType, builtin, ok := g.symbols.lookup(elementSymbol, s.name)
+		if !ok || builtin || packageQualifier(goType) != packageQualifier(headType) || goTypes[goType] {
+			continue
+		}
+		goTypes[goType] = true
+		substitutes = append(substitutes, &Substitute{Element: s.name, Type: s.typ, GoType: unqualify(goType)})
+	}
+	return substitutes
+}
+
+// claimGroups names the declarations generated beside the abstract elements
+// of schemas other elements substitute, after the components of the schemas
+// so the latter keep their names: the slice named after the element with the
+// Group suffix, and its soap.SubstitutionGroup.
+func (g *GoWSDL) claimGroups(schemas []*XSDSchema) {
+	for _, schema := range schemas {
+		for _, elm := range schema.Elements {
+			head := xml.Name{Space: schema.TargetNamespace, Local: elm.Name}
+			if !elm.Abstract || len(g.substitutes(head)) == 0 {
+				continue
+			}
+			name := g.symbols.names[symbol{elementSymbol, head}]
+			g.symbols.claim(symbol{groupSymbol, head}, name+"Group")
+			g.symbols.claim(symbol{substitutesSymbol, head}, makePrivate(name)+"Substitutes")
+		}
+	}
+}
+
+// packageQualifier returns the package name qualifying the Go type name, if
+// any.
+func packageQualifier(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
@@ -0,0 +1,320 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// symbolKind separates the symbol spaces of XML Schema: a type and an element
+// may share a QName while being distinct components. The wrappers of RPC style
+// messages have a space of their own, as they may share the QName of a global
+// element, and so have the declarations generated beside a type with subtypes
+// or an abstract element, named after it.
+type symbolKind int
+
+const (
+	typeSymbol symbolKind = iota
+	elementSymbol
+	wrapperSymbol
+	// The interface, value type and soap.TypeHierarchy of a type with
+	// subtypes, see claimHierarchies.
+	anySymbol
+	valueSymbol
+	hierarchySymbol
+	// The slice and soap.SubstitutionGroup of an abstract element, see
+	// claimGroups.
+	groupSymbol
+	substitutesSymbol
+)
+
+type symbol struct {
+	kind symbolKind
+	name xml.Name
+}
+
+// symbolTable assigns Go type names to the global types and elements of all
+// schemas, keyed by their QName. Components of different namespaces sharing a
+// local name are renamed deterministically, see claim.
+type symbolTable struct {
+	names map[symbol]string
+	// byLocal lists the Go names of the components with a given local name,
+	// in declaration order. It is used for names whose prefix doesn't resolve.
+	byLocal map[symbolKind]map[string][]string
+	owners  map[string]symbol
+	// types maps types to existing Go types, which are never generated.
+	types TypeMap
+}
+
+// reservedTypeNames are declared by the header template.
+var reservedTypeNames = []string{"AnyType", "AnyURI", "NCName"}
+
+// newSymbolTable names the components of schemas in the order the types
+// template generates them. Types are named before elements, so an element
+// whose type has the same name shares the generated type, unless it is an
+// abstract element heading substitutions, generated as an interface. Types
+// mapped by types aren't named. The components generated in another package
+// are named by external, qualified by the name of the package.
+func newSymbolTable(schemas []*XSDSchema, goName func(string) string, types TypeMap, external map[symbol]string, substitutions map[xml.Name][]substitution) *symbolTable {
+	st := &symbolTable{
+		names:   make(map[symbol]string),
+		byLocal: make(map[symbolKind]map[string][]string),
+		owners:  make(map[string]symbol),
+		types:   types,
+	}
+	for _, name := range reservedTypeNames {
+		st.owners[name] = symbol{kind: typeSymbol, name: xml.Name{Space: xmlschema11, Local: name}}
+	}
+
+	for _, schema := range schemas {
+		for _, simpleType := range schema.SimpleType {
+			st.claimType(xml.Name{Space: schema.TargetNamespace, Local: simpleType.Name}, goName(simpleType.Name))
+		}
+		for _, complexType := range schema.ComplexTypes {
+			st.claimType(xml.Name{Space: schema.TargetNamespace, Local: complexType.Name}, goName(complexType.Name))
+		}
+	}
+
+	for _, schema := range schemas {
+		for _, elm := range schema.Elements {
+			sym := symbol{elementSymbol, xml.Name{Space: schema.TargetNamespace, Local: elm.Name}}
+			name := goName(elm.Name)
+			if elm.Type != "" && len(substitutions[sym.name]) == 0 {
+				if typeName, _, ok := st.lookup(typeSymbol, schema.qname(elm.Type)); ok && typeName == name {
+					st.add(sym, name)
+					continue
+				}
+			}
+			st.claim(sym, name)
+		}
+	}
+
+	// External components come last, so names that don't resolve match
+	// the components generated in this package first.
+	syms := make([]symbol, 0, len(external))
+	for sym := range external {
+		syms = append(syms, sym)
+	}
+	sort.Slice(syms, func(i, j int) bool {
+		a, b := syms[i], syms[j]
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		if a.name.Space != b.name.Space {
+			return a.name.Space < b.name.Space
+		}
+		return a.name.Local < b.name.Local
+	})
+	for _, sym := range syms {
+		if _, ok := st.names[sym]; !ok {
+			st.add(sym, external[sym])
+		}
+	}
+
+	return st
+}
+
+// claimType claims name for the type qname, unless it is mapped.
+func (st *symbolTable) claimType(qname xml.Name, name string) {
+	if _, ok := st.types[qname]; !ok {
+		st.claim(symbol{typeSymbol, qname}, name)
+	}
+}
+
+// claim assigns name to sym, unless it is taken by another component. Then the
+// name is qualified with a suffix naming the kind of component when both live
+// in the same namespace, and with a prefix derived from the namespace
+// otherwise. A counter is appended as a last resort, and right away to the
+// names of the declarations generated beside a type.
+func (st *symbolTable) claim(sym symbol, name string) {
+	if _, ok := st.names[sym]; ok {
+		// Declared twice, e.g. by a schema included from several places.
+		return
+	}
+
+	owner, taken := st.owners[name]
+	if !taken {
+		st.add(sym, name)
+		return
+	}
+
+	candidate := name
+	if owner.name.Space == sym.name.Space {
+		switch sym.kind {
+		case typeSymbol:
+			candidate += "Type"
+		case elementSymbol, wrapperSymbol:
+			candidate += "Element"
+		}
+	} else {
+		candidate = namespacePrefix(sym.name.Space) + name
+	}
+
+	base := candidate
+	for i := 2; ; i++ {
+		if _, taken := st.owners[candidate]; !taken {
+			break
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+	st.add(sym, candidate)
+}
+
+func (st *symbolTable) add(sym symbol, name string) {
+	st.names[sym] = name
+	if _, ok := st.owners[name]; !ok {
+		st.owners[name] = sym
+	}
+	if st.byLocal[sym.kind] == nil {
+		st.byLocal[sym.kind] = make(map[string][]string)
+	}
+	st.byLocal[sym.kind][sym.name.Local] = append(st.byLocal[sym.kind][sym.name.Local], name)
+}
+
+// lookup returns the Go type generated for the component name of kind, and
+// whether it is a Go type mapped from an XML Schema built-in type, or by the
+// type map. Mapped types are used as built-in ones: by value. Names that
+// don't resolve to a declared component, usually because of a missing
+// namespace declaration, are matched by their local name.
+func (st *symbolTable) lookup(kind symbolKind, name xml.Name) (goName string, builtin, ok bool) {
+	if kind == typeSymbol {
+		if t, ok := st.types.lookup(name); ok {
+			return t.Type, true, true
+		}
+	}
+
+	builtinType, isBuiltin := xsd2GoTypes[strings.ToLower(name.Local)]
+	isBuiltin = isBuiltin && kind == typeSymbol
+	if isBuiltin && (name.Space == xmlschema11 || name.Space == soapEncodingNamespace) {
+		return builtinType, true, true
+	}
+
+	if goName, ok := st.names[symbol{kind, name}]; ok {
+		return goName, false, true
+	}
+	if names := st.byLocal[kind][name.Local]; len(names) > 0 {
+		return names[0], false, true
+	}
+	if isBuiltin {
+		if t, ok := st.types.lookup(xml.Name{Space: xmlschema11, Local: name.Local}); ok {
+			return t.Type, true, true
+		}
+		return builtinType, true, true
+	}
+	return "", false, false
+}
+
+// parseQName resolves a prefixed name through the namespace declarations in
+// scope. Unprefixed names belong to the default namespace. Undeclared prefixes
+// are kept as the namespace, so they never match a declared component.
+func parseQName(name string, xmlns map[string]string) xml.Name {
+	r := strings.SplitN(name, ":", 2)
+	if len(r) == 1 {
+		return xml.Name{Space: xmlns[""], Local: r[0]}
+	}
+	if ns, ok := xmlns[r[0]]; ok {
+		return xml.Name{Space: ns, Local: r[1]}
+	}
+	return xml.Name{Space: r[0], Local: r[1]}
+}
+
+// requalify returns a prefixed name resolving to qname through the namespace
+// declarations to. Namespaces unknown to to are declared under a new prefix.
+func requalify(qname xml.Name, to map[string]string) string {
+	if qname.Space == "" || qname.Local == "" {
+		return qname.Local
+	}
+
+	prefixes := make([]string, 0, len(to))
+	for prefix, ns := range to {
+		if ns == qname.Space {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+
+	if len(prefixes) == 0 {
+		var prefix string
+		for i := 1; ; i++ {
+			prefix = fmt.Sprintf("ns%d", i)
+			if _, ok := to[prefix]; !ok {
+				break
+			}
+		}
+		to[prefix] = qname.Space
+		prefixes = append(prefixes, prefix)
+	}
+
+	if prefixes[0] == "" {
+		return qname.Local
+	}
+	return prefixes[0] + ":" + qname.Local
+}
+
+// namespacePrefix derives an identifier from the last meaningful segment of a
+// namespace, e.g. "Billing" for "http://example.com/billing/v2" and "Epcis"
+// for "urn:epcglobal:epcis:xsd:1".
+func namespacePrefix(ns string) string {
+	segments := strings.FieldsFunc(ns, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		s := strings.ToLower(segments[i])
+		if isVersionSegment(s) {
+			continue
+		}
+		switch s {
+		case "xsd", "wsdl", "schema", "schemas", "ns", "namespace", "namespaces", "types", "http", "https", "urn", "www":
+			continue
+		}
+		return makePublic(segments[i])
+	}
+	return "Ns"
+}
+
+// elementNamespace returns the namespace of elm declared within schema. Global
+// elements always belong to the target namespace, local elements only when
+// their form is qualified.
+func elementNamespace(schema *XSDSchema, elm *XSDElement, global bool) string {
+	form := elm.Form
+	if form == "" {
+		form = schema.ElementFormDefault
+	}
+	if global || form == "qualified" {
+		return schema.TargetNamespace
+	}
+	return ""
+}
+
+// attributeNamespace returns the namespace of a local attribute declared
+// within schema, which is only set when the attribute's form is qualified.
+func attributeNamespace(schema *XSDSchema, attr *XSDAttribute) string {
+	form := attr.Form
+	if form == "" {
+		form = schema.AttributeFormDefault
+	}
+	if form == "qualified" {
+		return schema.TargetNamespace
+	}
+	return ""
+}
+
+func isVersionSegment(s string) bool {
+	s = strings.TrimPrefix(s, "v")
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,241 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Templates override the blocks the Go code is rendered with, keyed by block
+// name. An override is the text of the block, executed with the same data as
+// the block it replaces and the functions of FuncMap. It may define blocks of
+// its own.
+//
+// The types are rendered with these blocks, each executed with a *Type
+// unless noted otherwise:
+//
+//	Type         every type, dispatching on its Kind, then Methods
+//	SimpleType   a DefinedType, with its Enums and the Validate method
+//	             checking its Facets
+//	ComplexType  a StructType, with the Validate method checking its
+//	             fields with Checks
+//	Checks       the arguments of soap.Validate checking the Fields and
+//	             Choices of a StructType or of a *Field of anonymous type
+//	Hierarchy    after a StructType with Subtypes: its interface and
+//	             the Value type holding any of them
+//	SOAPArray    an ArrayType, with the Validate method checking its items
+//	RPCWrapper   an RPCType, with the Validate method checking its parts
+//	Interface    an InterfaceType, with the Group type holding its
+//	             Substitutes
+//	Methods      after every type, empty by default
+//	Fields       the []*Field of a struct
+//	Field        a *Field
+//	Tag          the struct tag of a *Field, without backquotes
+//	Facets       the soap.Facets literal of a *Facets
+//
+// The operations, the server and the headers are rendered with:
+//
+//	Service       a *Service: its interface and client
+//	Header        the *Model: header of the client, FileHeader and the
+//	              helper types
+//	FileHeader    the *Model: package clause and imports of a client file
+//	Server        the *Model: the server
+//	ServerHeader  the *Model: package clause and imports of the server
+//
+// For example, a Tag.tmpl adding a db tag to every field:
+//
+//	{{.Tag}}{{if .XMLName.Local}} db:"{{snake .XMLName.Local}}"{{end}}
+type Templates map[string]string
+
+// equal reports whether t and other override the same blocks the same way.
+func (t Templates) equal(other Templates) bool {
+	if len(t) != len(other) {
+		return false
+	}
+	for name, text := range t {
+		if o, ok := other[name]; !ok || o != text {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadTemplates reads the overrides in dir, one file per block named after
+// it with the .tmpl extension, e.g. Field.tmpl. Other files are ignored.
+func LoadTemplates(dir string) (Templates, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+
+	t := make(Templates, len(files))
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		t[strings.TrimSuffix(filepath.Base(file), ".tmpl")] = string(data)
+	}
+	return t, t.check()
+}
+
+// goTemplates are the templates the Go code is rendered with.
+var goTemplates = []struct {
+	name, text string
+}{
+	{"header", headerTmpl},
+	{"file_header", fileHeaderTmpl},
+	{"types", typesTmpl},
+	{"operations", opsTmpl},
+	{"server_header", serverHeaderTmpl},
+	{"server", serverTmpl},
+}
+
+// check fails when t overrides blocks the templates don't define.
+func (t Templates) check() error {
+	blocks := make(map[string]bool)
+	for _, gt := range goTemplates {
+		tmpl := template.Must(template.New(gt.name).Funcs(FuncMap()).Parse(gt.text))
+		for _, block := range tmpl.Templates() {
+			if block.Name() != gt.name {
+				blocks[block.Name()] = true
+			}
+		}
+	}
+
+	var unknown []string
+	for name := range t {
+		if !blocks[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	known := make([]string, 0, len(blocks))
+	for name := range blocks {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+	return fmt.Errorf("templates %s override no block, blocks are %s", strings.Join(unknown, ", "), strings.Join(known, ", "))
+}
+
+// FuncMap returns the functions available to the templates:
+//
+//	comment      formats a documentation as a Go comment, one // line per line
+//	goString     escapes the double quotes of a string for a Go string literal
+//	quote        quotes a string as a Go string literal, see strconv.Quote
+//	lower        lower cases a string
+//	upper        upper cases a string
+//	snake        converts an identifier to snake case, e.g. "customer_id" for
+//	             "CustomerID" or "customerId"
+//	makePublic   upper cases the first letter of an identifier
+//	makePrivate  lower cases the first letter of an identifier
+//	stripns      removes the prefix of a QName, e.g. "string" for "xs:string"
+//	unqualify    removes the package name qualifying a Go type, e.g. "Amount"
+//	             for "money.Amount"
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"comment":     comment,
+		"goString":    goString,
+		"quote":       strconv.Quote,
+		"lower":       strings.ToLower,
+		"upper":       strings.ToUpper,
+		"snake":       snakeCase,
+		"makePublic":  makePublic,
+		"makePrivate": makePrivate,
+		"stripns":     stripns,
+		"unqualify":   unqualify,
+	}
+}
+
+// render executes the template text named name with model, its blocks being
+// overridden by overrides.
+func render(name, text string, overrides Templates, model *Model) ([]byte, error) {
+	tmpl, err := template.New(name).Funcs(FuncMap()).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	for block, text := range overrides {
+		if block == name || tmpl.Lookup(block) == nil {
+			continue
+		}
+		if _, err := tmpl.New(block).Parse(text); err != nil {
+			return nil, fmt.Errorf("parsing template %s: %w", block, err)
+		}
+	}
+
+	data := new(bytes.Buffer)
+	if err := tmpl.Execute(data, model); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+func genTypes(model *Model, overrides Templates) ([]byte, error) {
+	return render("types", typesTmpl, overrides, model)
+}
+
+func genOperations(model *Model, overrides Templates) ([]byte, error) {
+	return render("operations", opsTmpl, overrides, model)
+}
+
+func genServer(model *Model, overrides Templates) ([]byte, error) {
+	return render("server", serverTmpl, overrides, model)
+}
+
+func genHeader(model *Model, overrides Templates) ([]byte, error) {
+	return render("header", headerTmpl, overrides, model)
+}
+
+func genFileHeader(model *Model, overrides Templates) ([]byte, error) {
+	return render("file_header", fileHeaderTmpl, overrides, model)
+}
+
+func genServerHeader(model *Model, overrides Templates) ([]byte, error) {
+	return render("server_header", serverHeaderTmpl, overrides, model)
+}
+
+// unqualify removes the package name qualifying goType.
+func unqualify(goType string) string {
+	if i := strings.LastIndex(goType, "."); i >= 0 {
+		return goType[i+1:]
+	}
+	return goType
+}
+
+// snakeCase converts an identifier to snake case. Runs of upper case letters
+// are kept together as acronyms.
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if r == '-' || r == '.' || r == ' ' || r == '_' {
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteRune('_')
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 && b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
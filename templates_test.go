@@ -0,0 +1,80 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestTemplates(t *testing.T) {
+	overrides, err := LoadTemplates("fixtures/templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := New("fixtures/stock.wsdl", WithTemplates(overrides), WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "TradePriceRequest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `type TradePriceRequest struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/stockquote.xsd TradePriceRequest"` + "`" + `
+
+	TickerSymbol	string	` + "`" + `xml:"tickerSymbol,omitempty" json:"tickerSymbol,omitempty" db:"ticker_symbol"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "XMLLocalName", "TradePriceRequest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `func (tradePriceRequest *TradePriceRequest) XMLLocalName() string {
+	return "TradePriceRequest"
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+}
+
+func TestTemplates_unknownBlock(t *testing.T) {
+	g, err := New("fixtures/stock.wsdl", WithTemplates(Templates{"Elements": ""}), WithLogger(log.New(ioutil.Discard, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.Start()
+	if err == nil || !strings.Contains(err.Error(), "templates Elements override no block") {
+		t.Errorf("got %v, want the unknown block to be reported", err)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"CustomerID":   "customer_id",
+		"customerId":   "customer_id",
+		"HTTPServer":   "http_server",
+		"tickerSymbol": "ticker_symbol",
+		"Get_info":     "get_info",
+		"order-line.2": "order_line_2",
+		"v2Address":    "v2_address",
+	}
+	for in, want := range tests {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
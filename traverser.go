@@ -2,7 +2,7 @@ package gowsdl
 
 import (
 	"encoding/xml"
-	"strings"
+	"sort"
 )
 
 type traverseMode int32
@@ -17,9 +17,16 @@ type traverser struct {
 	all []*XSDSchema
 	tm  traverseMode
 	// fields used by findNameByType mode
+	symbols              *symbolTable
 	typeName             string
-	foundElmName         string
+	foundElm             xml.Name
 	conflictingTypeUsage bool
+
+	// visited are the complex types traversed, which the elements of
+	// recursive groups make cyclic.
+	visited map[*XSDComplexType]bool
+	// expanding are the groups and attribute groups being inlined.
+	expanding map[interface{}]bool
 }
 
 func newTraverser(c *XSDSchema, all []*XSDSchema) *traverser {
@@ -27,6 +34,9 @@ func newTraverser(c *XSDSchema, all []*XSDSchema) *traverser {
 		c:   c,
 		all: all,
 		tm:  refResolution, // default traverse mode is refResolution
+
+		visited:   make(map[*XSDComplexType]bool),
+		expanding: make(map[interface{}]bool),
 	}
 }
 
@@ -40,23 +50,26 @@ func (t *traverser) traverse() {
 		t.traverseSimpleType(st)
 	}
 	for _, elm := range t.c.Elements {
-		t.traverseElement(elm)
+		t.traverseElement(elm, true)
 	}
 }
 
-// Given a type, check if there is an Element with that type, and return its name.
+// Given a type, check if there is an Element with that type, and return its
+// qualified name. Types are compared by the Go type generated for them, so
+// the search needs the symbols of all schemas.
 // If multiple elements with identical names of the given type are found,
 // the name is returned.
 // If multiple elements with different names of the given type are found,
 // the original type name is returned instead.
 // If no elements are found, the original type name is returned instead.
-func (t *traverser) findNameByType(name string) string {
+func (t *traverser) findNameByType(name xml.Name) xml.Name {
 	t.initFindNameByType(name)
 
 	// Search for elements of given type
 	for _, schema := range t.all {
+		t.c = schema
 		for _, elm := range schema.Elements {
-			t.traverseElement(elm)
+			t.traverseElement(elm, true)
 		}
 		for _, ct := range schema.ComplexTypes {
 			t.traverseComplexType(ct)
@@ -67,31 +80,35 @@ func (t *traverser) findNameByType(name string) string {
 	}
 
 	// Return found element name if given type is used only once
-	if len(t.foundElmName) > 0 && !t.conflictingTypeUsage {
-		return t.foundElmName
+	if len(t.foundElm.Local) > 0 && !t.conflictingTypeUsage {
+		return t.foundElm
 	}
 
 	// Return original type name
 	// No element found or conflicting element names found
-	return t.typeName
+	return name
 }
 
-func (t *traverser) initFindNameByType(name string) {
+func (t *traverser) initFindNameByType(name xml.Name) {
 	// Initialize fields for processing
 	t.tm = findNameByType
-	t.typeName = stripns(name)
-	t.foundElmName = ""
+	t.typeName, _, _ = t.symbols.lookup(typeSymbol, name)
+	t.foundElm = xml.Name{}
 	t.conflictingTypeUsage = false
+	t.visited = make(map[*XSDComplexType]bool)
 }
 
 func (t *traverser) traverseElements(ct []*XSDElement) {
 	for _, elm := range ct {
-		t.traverseElement(elm)
+		t.traverseElement(elm, false)
 	}
 }
 
-func (t *traverser) traverseElement(elm *XSDElement) {
-	t.findElmName(elm)
+func (t *traverser) traverseElement(elm *XSDElement, global bool) {
+	if elm.schema != nil {
+		defer t.setSchema(t.setSchema(elm.schema))
+	}
+	t.findElmName(elm, global)
 
 	if elm.ComplexType != nil {
 		t.traverseComplexType(elm.ComplexType)
@@ -101,42 +118,56 @@ func (t *traverser) traverseElement(elm *XSDElement) {
 	}
 }
 
-func (t *traverser) findElmName(elm *XSDElement) {
+func (t *traverser) findElmName(elm *XSDElement, global bool) {
 	// Check if we are called by findNameByType
 	if t.tm != findNameByType {
 		return
 	}
 
 	// Conflicting type usage already detected -> no need to search any further
-	if t.conflictingTypeUsage {
+	if t.conflictingTypeUsage || elm.Type == "" {
 		return
 	}
 
-	if stripns(elm.Type) == t.typeName {
-		if len(t.foundElmName) == 0 {
+	if goName, _, _ := t.symbols.lookup(typeSymbol, t.c.qname(elm.Type)); goName == t.typeName {
+		name := xml.Name{Space: elementNamespace(t.c, elm, global), Local: elm.Name}
+		if len(t.foundElm.Local) == 0 {
 			// First time usage t.typeName
-			t.foundElmName = elm.Name
-		} else if t.foundElmName != elm.Name {
+			t.foundElm = name
+		} else if t.foundElm != name {
 			// Duplicate use of t.typeName with different element names
 			t.conflictingTypeUsage = true
 		}
 	}
 }
-
 func (t *traverser) traverseSimpleType(st *XSDSimpleType) {
 }
 
 func (t *traverser) traverseComplexType(ct *XSDComplexType) {
+	if t.visited[ct] {
+		return
+	}
+	t.visited[ct] = true
+	if t.tm == refResolution {
+		t.inlineComplexTypeGroups(ct)
+	}
+
 	t.traverseElements(ct.Sequence)
-	t.traverseElements(ct.Choice)
-	t.traverseElements(ct.SequenceChoice)
+	t.traverseElements(choiceElements(ct.Choice))
+	t.traverseElements(choiceElements(ct.SequenceChoices...))
 	t.traverseElements(ct.All)
 	t.traverseAttributes(ct.Attributes)
 	t.traverseAttributes(ct.ComplexContent.Extension.Attributes)
 	t.traverseElements(ct.ComplexContent.Extension.Sequence)
-	t.traverseElements(ct.ComplexContent.Extension.Choice)
-	t.traverseElements(ct.ComplexContent.Extension.SequenceChoice)
+	t.traverseElements(choiceElements(ct.ComplexContent.Extension.Choice))
+	t.traverseElements(choiceElements(ct.ComplexContent.Extension.SequenceChoices...))
 	t.traverseAttributes(ct.SimpleContent.Extension.Attributes)
+	t.traverseElements(ct.ComplexContent.Restriction.Sequence)
+	t.traverseElements(choiceElements(ct.ComplexContent.Restriction.Choice))
+	t.traverseElements(choiceElements(ct.ComplexContent.Restriction.SequenceChoices...))
+	t.traverseElements(ct.ComplexContent.Restriction.All)
+	t.traverseAttributes(ct.ComplexContent.Restriction.Attributes)
+	t.traverseAttributes(ct.SimpleContent.Restriction.Attributes)
 }
 
 func (t *traverser) traverseAttributes(attrs []*XSDAttribute) {
@@ -150,13 +181,16 @@ func (t *traverser) traverseAttribute(attr *XSDAttribute) {
 	if t.tm != refResolution {
 		return
 	}
+	if attr.schema != nil {
+		defer t.setSchema(t.setSchema(attr.schema))
+	}
 
 	if attr.Ref != "" {
-		refAttr := t.getGlobalAttribute(attr.Ref)
+		refAttr, refSchema := t.getGlobalAttribute(attr.Ref)
 		if refAttr != nil && refAttr.Ref == "" {
 			t.traverseAttribute(refAttr)
 			attr.Name = refAttr.Name
-			attr.Type = refAttr.Type
+			attr.Type = requalify(refSchema.qname(refAttr.Type), t.c.Xmlns)
 			if attr.Fixed == "" {
 				attr.Fixed = refAttr.Fixed
 			}
@@ -169,34 +203,225 @@ func (t *traverser) traverseAttribute(attr *XSDAttribute) {
 	}
 }
 
-func (t *traverser) getGlobalAttribute(name string) *XSDAttribute {
-	ref := t.qname(name)
+func (t *traverser) getGlobalAttribute(name string) (*XSDAttribute, *XSDSchema) {
+	ref := t.c.qname(name)
 
 	for _, schema := range t.all {
 		if schema.TargetNamespace == ref.Space {
 			for _, attr := range schema.Attributes {
 				if attr.Name == ref.Local {
-					return attr
+					return attr, schema
 				}
 			}
 		}
 	}
 
-	return nil
+	return nil, nil
+}
+
+// setSchema makes schema the one whose names are resolved, returning the
+// previous one.
+func (t *traverser) setSchema(schema *XSDSchema) *XSDSchema {
+	prev := t.c
+	t.c = schema
+	return prev
+}
+
+// inlineComplexTypeGroups replaces the group and attribute group references
+// of ct by their content, in document order.
+func (t *traverser) inlineComplexTypeGroups(ct *XSDComplexType) {
+	ct.Choice = t.inlineChoice(ct.Choice)
+	ct.SequenceChoices = t.inlineChoices(ct.SequenceChoices)
+	for _, ref := range ct.Groups {
+		sequence, choices, all := t.groupContent(ref)
+		ct.Sequence = append(ct.Sequence, sequence...)
+		ct.SequenceChoices = append(ct.SequenceChoices, choices...)
+		ct.All = append(ct.All, all...)
+	}
+	ct.Sequence = t.inlineGroups(ct.Sequence, ct.SequenceGroups, &ct.SequenceChoices)
+	ct.Attributes = append(ct.Attributes, t.attributeGroupsContent(ct.AttributeGroups)...)
+	ct.Groups, ct.SequenceGroups, ct.AttributeGroups = nil, nil, nil
+
+	t.inlineExtensionGroups(&ct.ComplexContent.Extension)
+	t.inlineExtensionGroups(&ct.SimpleContent.Extension)
+	restriction := &ct.ComplexContent.Restriction
+	restriction.Choice = t.inlineChoice(restriction.Choice)
+	restriction.SequenceChoices = t.inlineChoices(restriction.SequenceChoices)
+	for _, ref := range restriction.Groups {
+		sequence, choices, all := t.groupContent(ref)
+		restriction.Sequence = append(restriction.Sequence, sequence...)
+		restriction.SequenceChoices = append(restriction.SequenceChoices, choices...)
+		restriction.All = append(restriction.All, all...)
+	}
+	restriction.Sequence = t.inlineGroups(restriction.Sequence, restriction.SequenceGroups, &restriction.SequenceChoices)
+	restriction.Attributes = append(restriction.Attributes, t.attributeGroupsContent(restriction.AttributeGroups)...)
+	restriction.Groups, restriction.SequenceGroups, restriction.AttributeGroups = nil, nil, nil
+
+	simple := &ct.SimpleContent.Restriction
+	simple.Attributes = append(simple.Attributes, t.attributeGroupsContent(simple.AttributeGroups)...)
+	simple.AttributeGroups = nil
+}
+
+func (t *traverser) inlineExtensionGroups(ext *XSDExtension) {
+	ext.Choice = t.inlineChoice(ext.Choice)
+	ext.SequenceChoices = t.inlineChoices(ext.SequenceChoices)
+	for _, ref := range ext.Groups {
+		sequence, choices, all := t.groupContent(ref)
+		ext.Sequence = append(append(ext.Sequence, sequence...), all...)
+		ext.SequenceChoices = append(ext.SequenceChoices, choices...)
+	}
+	ext.Sequence = t.inlineGroups(ext.Sequence, ext.SequenceGroups, &ext.SequenceChoices)
+	ext.Attributes = append(ext.Attributes, t.attributeGroupsContent(ext.AttributeGroups)...)
+	ext.Groups, ext.SequenceGroups, ext.AttributeGroups = nil, nil, nil
+}
+
+// inlineChoice returns a copy of choice with the content of its group
+// references inlined, or nil when choice is nil.
+func (t *traverser) inlineChoice(choice *XSDChoice) *XSDChoice {
+	if choice == nil {
+		return nil
+	}
+	return &XSDChoice{
+		MinOccurs: choice.MinOccurs,
+		MaxOccurs: choice.MaxOccurs,
+		Elements:  t.inlineGroups(choice.Elements, choice.Groups, nil),
+	}
+}
+
+func (t *traverser) inlineChoices(choices []*XSDChoice) []*XSDChoice {
+	inlined := make([]*XSDChoice, 0, len(choices))
+	for _, choice := range choices {
+		inlined = append(inlined, t.inlineChoice(choice))
+	}
+	return inlined
+}
+
+// inlineGroups returns the elements of a sequence or choice with the content
+// of its group references inlined at their position. The choices of groups
+// referenced within a sequence are added to choices, the choices of the
+// sequence, or inlined when nil.
+func (t *traverser) inlineGroups(elms []*XSDElement, refs []*XSDGroup, choices *[]*XSDChoice) []*XSDElement {
+	if len(refs) == 0 {
+		return elms
+	}
+	type particle struct {
+		pos  Position
+		elms []*XSDElement
+	}
+	particles := make([]particle, 0, len(elms)+len(refs))
+	for _, elm := range elms {
+		particles = append(particles, particle{elm.Pos, []*XSDElement{elm}})
+	}
+	for _, ref := range refs {
+		sequence, groupChoices, all := t.groupContent(ref)
+		content := append(sequence, all...)
+		if choices != nil {
+			*choices = append(*choices, groupChoices...)
+		} else {
+			content = append(content, choiceElements(groupChoices...)...)
+		}
+		particles = append(particles, particle{ref.Pos, content})
+	}
+	sort.SliceStable(particles, func(i, j int) bool {
+		a, b := particles[i].pos, particles[j].pos
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+
+	var inlined []*XSDElement
+	for _, p := range particles {
+		inlined = append(inlined, p.elms...)
+	}
+	return inlined
+}
+
+// groupContent returns copies of the elements of the group referenced by ref,
+// by compositor, its own group references inlined. Its choices are those of
+// its sequence, then its own. Unknown groups, and groups referencing
+// themselves, have no content.
+func (t *traverser) groupContent(ref *XSDGroup) (sequence []*XSDElement, choices []*XSDChoice, all []*XSDElement) {
+	group, schema := t.getGlobalGroup(t.c.qname(ref.Ref))
+	if group == nil || t.expanding[group] {
+		return nil, nil, nil
+	}
+	t.expanding[group] = true
+	defer delete(t.expanding, group)
+
+	prev := t.setSchema(schema)
+	choices = t.inlineChoices(group.SequenceChoices)
+	sequence = t.inlineGroups(group.Sequence, group.SequenceGroups, &choices)
+	if group.Choice != nil {
+		choices = append(choices, t.inlineChoice(group.Choice))
+	}
+	all = group.All
+	t.setSchema(prev)
+
+	// The elements are declared by the schema of the group, whose prefixes
+	// and forms their names and types follow.
+	copyElements := func(elms []*XSDElement) []*XSDElement {
+		copies := make([]*XSDElement, 0, len(elms))
+		for _, elm := range elms {
+			c := *elm
+			if c.schema == nil && schema != t.c {
+				c.schema = schema
+			}
+			copies = append(copies, &c)
+		}
+		return copies
+	}
+	for _, choice := range choices {
+		choice.Elements = copyElements(choice.Elements)
+	}
+	return copyElements(sequence), choices, copyElements(all)
+}
+
+// attributeGroupsContent returns copies of the attributes of the attribute
+// groups referenced by refs, their own references inlined.
+func (t *traverser) attributeGroupsContent(refs []*XSDAttributeGroup) []*XSDAttribute {
+	var attrs []*XSDAttribute
+	for _, ref := range refs {
+		group, schema := t.getGlobalAttributeGroup(t.c.qname(ref.Ref))
+		if group == nil || t.expanding[group] {
+			continue
+		}
+		t.expanding[group] = true
+		prev := t.setSchema(schema)
+		content := append(group.Attributes[:len(group.Attributes):len(group.Attributes)], t.attributeGroupsContent(group.AttributeGroups)...)
+		t.setSchema(prev)
+		delete(t.expanding, group)
+
+		for _, attr := range content {
+			c := *attr
+			if c.schema == nil && schema != t.c {
+				c.schema = schema
+			}
+			attrs = append(attrs, &c)
+		}
+	}
+	return attrs
 }
 
-// qname resolves QName into xml.Name.
-func (t *traverser) qname(name string) (qname xml.Name) {
-	x := strings.SplitN(name, ":", 2)
-	if len(x) == 1 {
-		qname.Local = x[0]
-	} else {
-		qname.Local = x[1]
-		qname.Space = x[0]
-		if ns, ok := t.c.Xmlns[qname.Space]; ok {
-			qname.Space = ns
+func (t *traverser) getGlobalGroup(name xml.Name) (*XSDGroup, *XSDSchema) {
+	for _, schema := range t.all {
+		if schema.TargetNamespace == name.Space {
+			for _, group := range schema.Groups {
+				if group.Name == name.Local {
+					return group, schema
+				}
+			}
 		}
 	}
+	return nil, nil
+}
 
-	return qname
+func (t *traverser) getGlobalAttributeGroup(name xml.Name) (*XSDAttributeGroup, *XSDSchema) {
+	for _, schema := range t.all {
+		if schema.TargetNamespace == name.Space {
+			for _, group := range schema.AttributeGroups {
+				if group.Name == name.Local {
+					return group, schema
+				}
+			}
+		}
+	}
+	return nil, nil
 }
@@ -6,220 +6,306 @@ package gowsdl
 
 var typesTmpl = `
 {{define "SimpleType"}}
-	{{$typeName := replaceReservedWords .Name | makePublic}}
 	{{if .Doc}} {{.Doc | comment}} {{end}}
-	{{if ne .List.ItemType ""}}
-		type {{$typeName}} []{{toGoType .List.ItemType false | removePointerFromType}}
-	{{else if ne .Union.MemberTypes ""}}
-		type {{$typeName}} string
-	{{else if .Union.SimpleType}}
-		type {{$typeName}} string
-	{{else if .Restriction.Base}}
-		type {{$typeName}} {{toGoType .Restriction.Base false | removePointerFromType}}
-    {{else}}
-		type {{$typeName}} interface{}
+	type {{.Name}} {{.Underlying}}
+
+	{{if eq .Underlying "soap.XSDDateTime"}}
+		func (xdt {{.Name}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+			return soap.XSDDateTime(xdt).MarshalXML(e, start)
+		}
+
+		func (xdt *{{.Name}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+			return (*soap.XSDDateTime)(xdt).UnmarshalXML(d, start)
+		}
+	{{else if eq .Underlying "soap.XSDDate"}}
+		func (xd {{.Name}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+			return soap.XSDDate(xd).MarshalXML(e, start)
+		}
+
+		func (xd *{{.Name}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+			return (*soap.XSDDate)(xd).UnmarshalXML(d, start)
+		}
+	{{else if eq .Underlying "soap.XSDTime"}}
+		func (xt {{.Name}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+			return soap.XSDTime(xt).MarshalXML(e, start)
+		}
+
+		func (xt *{{.Name}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+			return (*soap.XSDTime)(xt).UnmarshalXML(d, start)
+		}
 	{{end}}
 
-	{{if .Restriction.Enumeration}}
+	{{if .Enums}}
 	const (
-		{{with .Restriction}}
-			{{range .Enumeration}}
-				{{if .Doc}} {{.Doc | comment}} {{end}}
-				{{$typeName}}{{$value := replaceReservedWords .Value}}{{$value | makePublic}} {{$typeName}} = "{{goString .Value}}" {{end}}
-		{{end}}
+		{{range .Enums}}
+			{{if .Doc}} {{.Doc | comment}} {{end}}
+			{{.Name}} {{$.Name}} = "{{goString .Value}}" {{end}}
 	)
 	{{end}}
-{{end}}
 
-{{define "ComplexContent"}}
-	{{$baseType := toGoType .Extension.Base false}}
-	{{ if $baseType }}
-		{{$baseType}}
+	{{with .Facets}}
+		var {{makePrivate $.Name}}Facets = {{template "Facets" .}}
 	{{end}}
 
-	{{template "Elements" .Extension.Sequence}}
-	{{template "Elements" .Extension.Choice}}
-	{{template "Elements" .Extension.SequenceChoice}}
-	{{template "Attributes" .Extension.Attributes}}
+	// Validate returns the soap.ValidationErrors of the constraints of {{.QName.Local}} v violates.
+	func (v {{.Name}}) Validate() error {
+		{{- if .Facets}}
+			return soap.Validate({{makePrivate .Name}}Facets.Validate(v){{if .Validated}}, soap.Field("", ({{.Underlying}})(v)){{end}})
+		{{- else if .Validated}}
+			return soap.Field("", ({{.Underlying}})(v))
+		{{- else}}
+			return nil
+		{{- end}}
+	}
 {{end}}
 
-{{define "Attributes"}}
-    {{ $targetNamespace := getNS }}
-	{{range .}}
+{{define "Facets"}}soap.Facets{ {{- with .Enumeration}}Enumeration: []string{ {{- range $i, $v := .}}{{if $i}}, {{end}}{{quote $v}}{{end}}}, {{end}}
+	{{- with .Pattern}}Pattern: {{quote .}}, {{end}}
+	{{- with .MinInclusive}}MinInclusive: {{quote .}}, {{end}}
+	{{- with .MaxInclusive}}MaxInclusive: {{quote .}}, {{end}}
+	{{- with .Length}}Length: {{.}}, {{end}}
+	{{- with .MinLength}}MinLength: {{.}}, {{end}}
+	{{- with .MaxLength}}MaxLength: {{.}}, {{end}}}{{end}}
+
+{{define "Field"}}
+	{{if .Embedded}}
+		{{.Type}}
+	{{else if .Struct}}
+		{{if .Doc}} {{.Doc | comment}} {{end}}
+		{{.Name}} {{if .Repeated}}[]{{end}}struct {
+			{{template "Fields" .Fields}}
+		} ` + "`{{template \"Tag\" .}}`" + `
+	{{else}}
 		{{if .Doc}} {{.Doc | comment}} {{end}}
-		{{ if ne .Type "" }}
-			{{ normalize .Name | makeFieldPublic}} {{toGoType .Type false}} ` + "`" + `xml:"{{with $targetNamespace}}{{.}} {{end}}{{.Name}},attr,omitempty" json:"{{.Name}},omitempty"` + "`" + `
-		{{ else }}
-			{{ normalize .Name | makeFieldPublic}} string ` + "`" + `xml:"{{with $targetNamespace}}{{.}} {{end}}{{.Name}},attr,omitempty" json:"{{.Name}},omitempty"` + "`" + `
-		{{ end }}
+		{{.Name}} {{.Type}} ` + "`{{template \"Tag\" .}}`" + `
 	{{end}}
 {{end}}
 
-{{define "SimpleContent"}}
-	Value {{toGoType .Extension.Base false}} ` + "`xml:\",chardata\" json:\"-,\"`" + `
-	{{template "Attributes" .Extension.Attributes}}
-{{end}}
+{{define "Tag"}}{{.Tag}}{{end}}
 
-{{define "ComplexTypeInline"}}
-	{{replaceReservedWords .Name | makePublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}struct {
-	{{with .ComplexType}}
-		{{if ne .ComplexContent.Extension.Base ""}}
-			{{template "ComplexContent" .ComplexContent}}
-		{{else if ne .SimpleContent.Extension.Base ""}}
-			{{template "SimpleContent" .SimpleContent}}
-		{{else}}
-			{{template "Elements" .Sequence}}
-			{{template "Elements" .Choice}}
-			{{template "Elements" .SequenceChoice}}
-			{{template "Elements" .All}}
-			{{template "Attributes" .Attributes}}
-		{{end}}
+{{define "Fields"}}
+	{{range .}}
+		{{template "Field" .}}
 	{{end}}
-	} ` + "`" + `xml:"{{.Name}},omitempty" json:"{{.Name}},omitempty"` + "`" + `
 {{end}}
 
-{{define "Elements"}}
-	{{range .}}
-		{{if ne .Ref ""}}
-			{{removeNS .Ref | replaceReservedWords  | makePublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{toGoType .Ref .Nillable }} ` + "`" + `xml:"{{.Ref | removeNS}},omitempty" json:"{{.Ref | removeNS}},omitempty"` + "`" + `
-		{{else}}
-		{{if not .Type}}
-			{{if .SimpleType}}
-				{{if .Doc}} {{.Doc | comment}} {{end}}
-				{{if ne .SimpleType.List.ItemType ""}}
-					{{ normalize .Name | makeFieldPublic}} []{{toGoType .SimpleType.List.ItemType false}} ` + "`" + `xml:"{{.Name}},omitempty" json:"{{.Name}},omitempty"` + "`" + `
-				{{else}}
-					{{ normalize .Name | makeFieldPublic}} {{toGoType .SimpleType.Restriction.Base false}} ` + "`" + `xml:"{{.Name}},omitempty" json:"{{.Name}},omitempty"` + "`" + `
-				{{end}}
-			{{else}}
-				{{template "ComplexTypeInline" .}}
+{{define "ComplexType"}}
+	{{if .Doc}} {{.Doc | comment}} {{end}}
+	type {{.Name}} struct {
+		{{with .XMLName}}
+			{{if .Local}}
+				XMLName xml.Name ` + "`" + `xml:"{{with .Space}}{{.}} {{end}}{{.Local}}"` + "`" + `
 			{{end}}
-		{{else}}
-			{{if .Doc}}{{.Doc | comment}} {{end}}
-			{{replaceAttrReservedWords .Name | makeFieldPublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{toGoType .Type .Nillable }} ` + "`" + `xml:"{{.Name}},omitempty" json:"{{.Name}},omitempty"` + "`" + ` {{end}}
 		{{end}}
-	{{end}}
+
+		{{template "Fields" .Fields}}
+	}
+
+	// Validate returns the soap.ValidationErrors of the constraints of {{.QName.Local}} t violates.
+	func (t *{{.Name}}) Validate() error {
+		{{- $checked := .Choices}}
+		{{- range .Fields}}
+			{{- if or .Embedded (and .Required .Omittable) .Facets .Validated (and .Repeated (or .MinOccurs .MaxOccurs))}}
+				{{- $checked = true}}
+			{{- end}}
+		{{- end}}
+		{{- if $checked}}
+			return soap.Validate({{template "Checks" .}}
+			)
+		{{- else}}
+			return nil
+		{{- end}}
+	}
 {{end}}
 
-{{define "Any"}}
-	{{range .}}
-		Items     []string ` + "`" + `xml:",any" json:"items,omitempty"` + "`" + `
-	{{end}}
+{{define "Checks"}}
+	{{- range $f := .Fields}}
+		{{- if .Embedded}}
+			soap.Field("", t.{{.Name}}),
+		{{- else if not .Any}}
+			{{- if and .Repeated (or .MinOccurs .MaxOccurs)}}
+				soap.Occurs({{quote .Name}}, len(t.{{.Name}}), {{.MinOccurs}}, {{.MaxOccurs}}),
+			{{- else if and .Required .Omittable}}
+				soap.Required({{quote .Name}}, t.{{.Name}}),
+			{{- end}}
+			{{- if and .Facets .Required}}
+				soap.Prefix({{quote .Name}}, ({{template "Facets" .Facets}}).Validate(t.{{.Name}})),
+			{{- else if .Facets}}
+				({{template "Facets" .Facets}}).ValidateField({{quote .Name}}, t.{{.Name}}),
+			{{- end}}
+			{{- if and .Struct .Validated .Repeated}}
+				soap.Each({{quote .Name}}, len(t.{{.Name}}), func(i int) error {
+					t := &t.{{.Name}}[i]
+					return soap.Validate({{template "Checks" .}}
+					)
+				}),
+			{{- else if and .Struct .Validated .Required}}
+				soap.Prefix({{quote .Name}}, func() error {
+					t := &t.{{.Name}}
+					return soap.Validate({{template "Checks" .}}
+					)
+				}()),
+			{{- else if and .Struct .Validated}}
+				soap.Optional({{quote .Name}}, t.{{.Name}}, func() error {
+					t := &t.{{.Name}}
+					return soap.Validate({{template "Checks" .}}
+					)
+				}),
+			{{- else if .Validated}}
+				soap.Field({{quote .Name}}, t.{{.Name}}),
+			{{- end}}
+		{{- end}}
+	{{- end}}
+	{{- range .Choices}}
+		soap.Choice([]string{ {{- range $i, $name := .}}{{if $i}}, {{end}}{{quote $name}}{{end}}}, {{range $i, $name := .}}{{if $i}}, {{end}}t.{{$name}}{{end}}),
+	{{- end}}
+{{- end}}
+
+{{define "RPCWrapper"}}
+	type {{.Name}} struct {
+		XMLName xml.Name ` + "`" + `xml:"{{.XMLName.Space}} {{.XMLName.Local}}"` + "`" + `
+		{{template "Fields" .Fields}}
+	}
+
+	func (r {{.Name}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		return soap.EncodeRPC(e, xml.Name{Space: "{{.XMLName.Space}}", Local: "{{.XMLName.Local}}"}, "{{.EncodingStyle}}",{{range .Fields}}
+			soap.RPCPart{Name: "{{.XMLName.Local}}", {{if $.EncodingStyle}}Type: xml.Name{Space: "{{.XSIType.Space}}", Local: "{{.XSIType.Local}}"}, {{end}}Value: r.{{.Name}}},{{end}}
+		)
+	}
+
+	// Validate returns the soap.ValidationErrors of the constraints the parts of r violate.
+	func (r {{.Name}}) Validate() error {
+		{{- $checked := false}}
+		{{- range .Fields}}{{if .Validated}}{{$checked = true}}{{end}}{{end}}
+		{{- if $checked}}
+			return soap.Validate({{range .Fields}}{{if .Validated}}
+				soap.Field({{quote .Name}}, r.{{.Name}}),{{end}}{{end}}
+			)
+		{{- else}}
+			return nil
+		{{- end}}
+	}
 {{end}}
 
-{{range .Schemas}}
-	{{ $targetNamespace := setNS .TargetNamespace }}
+{{define "SOAPArray"}}
+	type {{.Name}} {{.Underlying}}
 
-	{{range .SimpleType}}
-		{{template "SimpleType" .}}
-	{{end}}
+	// Validate returns the soap.ValidationErrors of the constraints the items of a violate.
+	func (a {{.Name}}) Validate() error {
+		{{- if .Validated}}
+			return soap.Field("", ({{.Underlying}})(a))
+		{{- else}}
+			return nil
+		{{- end}}
+	}
 
-	{{range .Elements}}
-		{{$name := .Name}}
-		{{$typeName := replaceReservedWords $name | makePublic}}
-		{{if not .Type}}
-			{{/* ComplexTypeLocal */}}
-			{{with .ComplexType}}
-				type {{$typeName}} struct {
-					XMLName xml.Name ` + "`xml:\"{{$targetNamespace}} {{$name}}\"`" + `
-					{{if ne .ComplexContent.Extension.Base ""}}
-						{{template "ComplexContent" .ComplexContent}}
-					{{else if ne .SimpleContent.Extension.Base ""}}
-						{{template "SimpleContent" .SimpleContent}}
-					{{else}}
-						{{template "Elements" .Sequence}}
-						{{template "Any" .Any}}
-						{{template "Elements" .Choice}}
-						{{template "Elements" .SequenceChoice}}
-						{{template "Elements" .All}}
-						{{template "Attributes" .Attributes}}
-					{{end}}
-				}
-			{{end}}
-			{{/* SimpleTypeLocal */}}
-			{{with .SimpleType}}
-				{{if .Doc}} {{.Doc | comment}} {{end}}
-				{{if ne .List.ItemType ""}}
-					type {{$typeName}} []{{toGoType .List.ItemType false | removePointerFromType}}
-				{{else if ne .Union.MemberTypes ""}}
-					type {{$typeName}} string
-				{{else if .Union.SimpleType}}
-					type {{$typeName}} string
-				{{else if .Restriction.Base}}
-					type {{$typeName}} {{toGoType .Restriction.Base false | removePointerFromType}}
-				{{else}}
-					type {{$typeName}} interface{}
-				{{end}}
-			
-				{{if .Restriction.Enumeration}}
-				const (
-					{{with .Restriction}}
-						{{range .Enumeration}}
-							{{if .Doc}} {{.Doc | comment}} {{end}}
-							{{$typeName}}{{$value := replaceReservedWords .Value}}{{$value | makePublic}} {{$typeName}} = "{{goString .Value}}" {{end}}
-					{{end}}
-				)
-				{{end}}
-			{{end}}
-		{{else}}
-			{{$type := toGoType .Type .Nillable | removePointerFromType}}
-			{{if ne ($typeName) ($type)}}
-				type {{$typeName}} {{$type}}
-				{{if eq ($type) ("soap.XSDDateTime")}}
-					func (xdt {{$typeName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
-						return soap.XSDDateTime(xdt).MarshalXML(e, start)
-					}
-
-					func (xdt *{{$typeName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-						return (*soap.XSDDateTime)(xdt).UnmarshalXML(d, start)
-					}
-				{{else if eq ($type) ("soap.XSDDate")}}
-					func (xd {{$typeName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
-						return soap.XSDDate(xd).MarshalXML(e, start)
-					}
-
-					func (xd *{{$typeName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-						return (*soap.XSDDate)(xd).UnmarshalXML(d, start)
-					}
-				{{else if eq ($type) ("soap.XSDTime")}}
-					func (xt {{$typeName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
-						return soap.XSDTime(xt).MarshalXML(e, start)
-					}
-
-					func (xt *{{$typeName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-						return (*soap.XSDTime)(xt).UnmarshalXML(d, start)
-					}
-				{{end}}
-			{{end}}
-		{{end}}
+	func (a {{.Name}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		return soap.MarshalArray(e, start, xml.Name{Space: "{{.Item.Space}}", Local: "{{.Item.Local}}"}, {{.Underlying}}(a))
+	}
+
+	func (a *{{.Name}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		return soap.UnmarshalArray(d, start, (*{{.Underlying}})(a))
+	}
+{{end}}
+
+{{define "Interface"}}
+	{{if .Doc}} {{.Doc | comment}} {{else}} // {{.Name}} is implemented by the elements substituting {{.QName.Local}}. {{end}}
+	type {{.Name}} interface {
+		is{{.Name}}()
+	}
+
+	{{range .Substitutes}}
+		func (*{{.GoType}}) is{{$.Name}}() {}
 	{{end}}
 
-	{{range .ComplexTypes}}
-		{{/* ComplexTypeGlobal */}}
-		{{$typeName := replaceReservedWords .Name | makePublic}}
-		{{if and (eq (len .SimpleContent.Extension.Attributes) 0) (eq (toGoType .SimpleContent.Extension.Base false) "string") }}
-			type {{$typeName}} string
-		{{else}}
-			type {{$typeName}} struct {
-				{{$type := findNameByType .Name}}
-				{{if ne .Name $type}}
-					XMLName xml.Name ` + "`xml:\"{{$targetNamespace}} {{$type}}\"`" + `
-				{{end}}
-
-				{{if ne .ComplexContent.Extension.Base ""}}
-					{{template "ComplexContent" .ComplexContent}}
-				{{else if ne .SimpleContent.Extension.Base ""}}
-					{{template "SimpleContent" .SimpleContent}}
-				{{else}}
-					{{template "Elements" .Sequence}}
-					{{template "Any" .Any}}
-					{{template "Elements" .Choice}}
-					{{template "Elements" .SequenceChoice}}
-					{{template "Elements" .All}}
-					{{template "Attributes" .Attributes}}
-				{{end}}
+	// {{.GroupName}} holds elements of the substitution group of {{.QName.Local}}.
+	type {{.GroupName}} []{{.Name}}
+
+	var {{.SubstitutesName}} = soap.SubstitutionGroup{ {{range .Substitutes}}
+		{Name: xml.Name{Space: "{{.Element.Space}}", Local: "{{.Element.Local}}"}, {{if .Type.Local}}Type: xml.Name{Space: "{{.Type.Space}}", Local: "{{.Type.Local}}"}, {{end}}New: func() interface{} { return new({{.GoType}}) }},{{end}}
+	}
+
+	func (g {{.GroupName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		for _, v := range g {
+			if err := {{.SubstitutesName}}.Encode(e, v); err != nil {
+				return err
 			}
+		}
+		return nil
+	}
+
+	// Validate returns the soap.ValidationErrors of the constraints the elements of g violate.
+	func (g {{.GroupName}}) Validate() error {
+		return soap.Field("", []{{.Name}}(g))
+	}
+
+	func (g *{{.GroupName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		v, err := {{.SubstitutesName}}.Decode(d, start)
+		if v != nil {
+			*g = append(*g, v.({{.Name}}))
+		}
+		return err
+	}
+{{end}}
+
+{{define "Hierarchy"}}
+	// {{.AnyName}} is implemented by {{.Name}} and the types derived from it.
+	type {{.AnyName}} interface {
+		is{{.Name}}()
+	}
+
+	func (*{{.Name}}) is{{.Name}}() {}
+
+	// {{.ValueName}} holds a value of {{.Name}} or of a type derived from it,
+	// announced through xsi:type.
+	type {{.ValueName}} struct {
+		Value {{.AnyName}}
+	}
+
+	var {{.HierarchyName}} = soap.TypeHierarchy{
+		{Type: xml.Name{Space: "{{.QName.Space}}", Local: "{{.QName.Local}}"}, New: func() interface{} { return new({{.Name}}) }},{{range .Subtypes}}
+		{Type: xml.Name{Space: "{{.Type.Space}}", Local: "{{.Type.Local}}"}, New: func() interface{} { return new({{.GoType}}) }},{{end}}
+	}
+
+	// Validate returns the soap.ValidationErrors of the constraints the value of v violates.
+	func (v {{.ValueName}}) Validate() error {
+		return soap.Field("", v.Value)
+	}
+
+	func (v {{.ValueName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		return {{.HierarchyName}}.Encode(e, start, v.Value)
+	}
+
+	func (v *{{.ValueName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		x, err := {{.HierarchyName}}.Decode(d, start)
+		if x != nil {
+			v.Value = x.({{.AnyName}})
+		}
+		return err
+	}
+{{end}}
+
+{{define "Methods"}}{{end}}
+
+{{define "Type"}}
+	{{if eq .Kind "struct"}}
+		{{template "ComplexType" .}}
+		{{if .Subtypes}}
+			{{template "Hierarchy" .}}
 		{{end}}
+	{{else if eq .Kind "array"}}
+		{{template "SOAPArray" .}}
+	{{else if eq .Kind "rpc"}}
+		{{template "RPCWrapper" .}}
+	{{else if eq .Kind "interface"}}
+		{{template "Interface" .}}
+	{{else}}
+		{{template "SimpleType" .}}
 	{{end}}
+	{{template "Methods" .}}
+{{end}}
+
+{{range .Types}}
+	{{template "Type" .}}
 {{end}}
 `
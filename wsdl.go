@@ -20,16 +20,29 @@ type WSDL struct {
 	PortTypes       []*WSDLPortType   `xml:"http://schemas.xmlsoap.org/wsdl/ portType"`
 	Binding         []*WSDLBinding    `xml:"http://schemas.xmlsoap.org/wsdl/ binding"`
 	Service         []*WSDLService    `xml:"http://schemas.xmlsoap.org/wsdl/ service"`
+
+	Declaration
 }
 
 // UnmarshalXML implements interface xml.Unmarshaler for XSDSchema.
 func (w *WSDL) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	w.Xmlns = make(map[string]string)
 	for _, attr := range start.Attr {
+		if attr.Name == positionAttr {
+			if err := w.Pos.UnmarshalXMLAttr(attr); err != nil {
+				return err
+			}
+			continue
+		}
 		if attr.Name.Space == "xmlns" {
 			w.Xmlns[attr.Name.Local] = attr.Value
 			continue
 		}
+		if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			// The default namespace is kept under the empty prefix.
+			w.Xmlns[""] = attr.Value
+			continue
+		}
 
 		switch attr.Name.Local {
 		case "name":
@@ -116,6 +129,8 @@ Loop:
 type WSDLImport struct {
 	Namespace string `xml:"namespace,attr"`
 	Location  string `xml:"location,attr"`
+
+	Declaration
 }
 
 // WSDLType represents the entry point for deserializing XSD schemas used by the WSDL file.
@@ -129,6 +144,8 @@ type WSDLPart struct {
 	Name    string `xml:"name,attr"`
 	Element string `xml:"element,attr"`
 	Type    string `xml:"type,attr"`
+
+	Declaration
 }
 
 // WSDLMessage represents a function, which in turn has one or more parameters.
@@ -136,42 +153,54 @@ type WSDLMessage struct {
 	Name  string      `xml:"name,attr"`
 	Doc   string      `xml:"documentation"`
 	Parts []*WSDLPart `xml:"http://schemas.xmlsoap.org/wsdl/ part"`
+
+	Declaration
 }
 
 // WSDLFault represents a WSDL fault message.
 type WSDLFault struct {
-	Name      string        `xml:"name,attr"`
-	Message   string        `xml:"message,attr"`
-	Doc       string        `xml:"documentation"`
-	SOAPFault WSDLSOAPFault `xml:"http://schemas.xmlsoap.org/wsdl/soap/ fault"`
+	Name        string        `xml:"name,attr"`
+	Message     string        `xml:"message,attr"`
+	Doc         string        `xml:"documentation"`
+	SOAPFault   WSDLSOAPFault `xml:"http://schemas.xmlsoap.org/wsdl/soap/ fault"`
+	SOAP12Fault WSDLSOAPFault `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ fault"`
+
+	Declaration
 }
 
 // WSDLInput represents a WSDL input message.
 type WSDLInput struct {
-	Name       string            `xml:"name,attr"`
-	Message    string            `xml:"message,attr"`
-	Doc        string            `xml:"documentation"`
-	SOAPBody   WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap/ body"`
-	SOAPHeader []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap/ header"`
+	Name         string            `xml:"name,attr"`
+	Message      string            `xml:"message,attr"`
+	Doc          string            `xml:"documentation"`
+	SOAPBody     WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap/ body"`
+	SOAPHeader   []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap/ header"`
+	SOAP12Body   WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ body"`
+	SOAP12Header []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ header"`
 }
 
 // WSDLOutput represents a WSDL output message.
 type WSDLOutput struct {
-	Name       string            `xml:"name,attr"`
-	Message    string            `xml:"message,attr"`
-	Doc        string            `xml:"documentation"`
-	SOAPBody   WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap/ body"`
-	SOAPHeader []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap/ header"`
+	Name         string            `xml:"name,attr"`
+	Message      string            `xml:"message,attr"`
+	Doc          string            `xml:"documentation"`
+	SOAPBody     WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap/ body"`
+	SOAPHeader   []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap/ header"`
+	SOAP12Body   WSDLSOAPBody      `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ body"`
+	SOAP12Header []*WSDLSOAPHeader `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ header"`
 }
 
 // WSDLOperation represents the contract of an entire operation or function.
 type WSDLOperation struct {
-	Name          string            `xml:"name,attr"`
-	Doc           string            `xml:"documentation"`
-	Input         WSDLInput         `xml:"input"`
-	Output        WSDLOutput        `xml:"output"`
-	Faults        []*WSDLFault      `xml:"fault"`
-	SOAPOperation WSDLSOAPOperation `xml:"http://schemas.xmlsoap.org/wsdl/soap/ operation"`
+	Name            string            `xml:"name,attr"`
+	Doc             string            `xml:"documentation"`
+	Input           WSDLInput         `xml:"input"`
+	Output          WSDLOutput        `xml:"output"`
+	Faults          []*WSDLFault      `xml:"fault"`
+	SOAPOperation   WSDLSOAPOperation `xml:"http://schemas.xmlsoap.org/wsdl/soap/ operation"`
+	SOAP12Operation WSDLSOAPOperation `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ operation"`
+
+	Declaration
 }
 
 // WSDLPortType defines the service, operations that can be performed and the messages involved.
@@ -180,6 +209,8 @@ type WSDLPortType struct {
 	Name       string           `xml:"name,attr"`
 	Doc        string           `xml:"documentation"`
 	Operations []*WSDLOperation `xml:"http://schemas.xmlsoap.org/wsdl/ operation"`
+
+	Declaration
 }
 
 // WSDLSOAPBinding represents a SOAP binding to the web service.
@@ -236,19 +267,30 @@ type WSDLSOAPAddress struct {
 
 // WSDLBinding defines only a SOAP binding and its operations
 type WSDLBinding struct {
-	Name        string           `xml:"name,attr"`
-	Type        string           `xml:"type,attr"`
-	Doc         string           `xml:"documentation"`
-	SOAPBinding WSDLSOAPBinding  `xml:"http://schemas.xmlsoap.org/wsdl/soap/ binding"`
-	Operations  []*WSDLOperation `xml:"http://schemas.xmlsoap.org/wsdl/ operation"`
+	Name          string           `xml:"name,attr"`
+	Type          string           `xml:"type,attr"`
+	Doc           string           `xml:"documentation"`
+	SOAPBinding   WSDLSOAPBinding  `xml:"http://schemas.xmlsoap.org/wsdl/soap/ binding"`
+	SOAP12Binding *WSDLSOAPBinding `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ binding"`
+	Operations    []*WSDLOperation `xml:"http://schemas.xmlsoap.org/wsdl/ operation"`
+
+	Declaration
+}
+
+// IsSOAP12 reports whether the binding uses the SOAP 1.2 protocol.
+func (b *WSDLBinding) IsSOAP12() bool {
+	return b.SOAP12Binding != nil
 }
 
 // WSDLPort defines the properties for a SOAP port only.
 type WSDLPort struct {
-	Name        string          `xml:"name,attr"`
-	Binding     string          `xml:"binding,attr"`
-	Doc         string          `xml:"documentation"`
-	SOAPAddress WSDLSOAPAddress `xml:"http://schemas.xmlsoap.org/wsdl/soap/ address"`
+	Name          string          `xml:"name,attr"`
+	Binding       string          `xml:"binding,attr"`
+	Doc           string          `xml:"documentation"`
+	SOAPAddress   WSDLSOAPAddress `xml:"http://schemas.xmlsoap.org/wsdl/soap/ address"`
+	SOAP12Address WSDLSOAPAddress `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ address"`
+
+	Declaration
 }
 
 // WSDLService defines the list of SOAP services associated with the WSDL.
@@ -256,4 +298,6 @@ type WSDLService struct {
 	Name  string      `xml:"name,attr"`
 	Doc   string      `xml:"documentation"`
 	Ports []*WSDLPort `xml:"http://schemas.xmlsoap.org/wsdl/ port"`
+
+	Declaration
 }
@@ -22,3 +22,48 @@ func TestUnmarshal(t *testing.T) {
 		t.Errorf("incorrect result\ngot:  %#v\nwant: %#v", err, nil)
 	}
 }
+
+func TestUnmarshalPositions(t *testing.T) {
+	data := []byte(`<?xml version="1.0"?>
+<wsdl:definitions xmlns:wsdl="http://schemas.xmlsoap.org/wsdl/" xmlns:s="http://www.w3.org/2001/XMLSchema">
+  <wsdl:types>
+    <s:schema targetNamespace="urn:example">
+      <s:complexType name="Order">
+        <s:sequence>
+          <s:element
+              name="Id" type="s:string"/>
+        </s:sequence>
+      </s:complexType>
+    </s:schema>
+  </wsdl:types>
+  <wsdl:message name="PlaceOrder"><wsdl:part name="parameters" element="tns:Order"/></wsdl:message>
+  <wsdl:portType name="Orders">
+    <wsdl:operation name="PlaceOrder"/>
+  </wsdl:portType>
+</wsdl:definitions>`)
+
+	w := new(WSDL)
+	if err := decodeSource("orders.wsdl", data, w); err != nil {
+		t.Fatal(err)
+	}
+
+	schema := w.Types.Schemas[0]
+	tests := []struct {
+		name     string
+		pos      Position
+		expected string
+	}{
+		{"definitions", w.Pos, "orders.wsdl:2:1"},
+		{"schema", schema.Pos, "orders.wsdl:4:5"},
+		{"complexType", schema.ComplexTypes[0].Pos, "orders.wsdl:5:7"},
+		{"element", schema.ComplexTypes[0].Sequence[0].Pos, "orders.wsdl:7:11"},
+		{"message", w.Messages[0].Pos, "orders.wsdl:13:3"},
+		{"part", w.Messages[0].Parts[0].Pos, "orders.wsdl:13:35"},
+		{"operation", w.PortTypes[0].Operations[0].Pos, "orders.wsdl:15:5"},
+	}
+	for _, test := range tests {
+		if test.pos.String() != test.expected {
+			t.Errorf("%s: got %s wanted %s", test.name, test.pos, test.expected)
+		}
+	}
+}
@@ -6,25 +6,31 @@ package gowsdl
 
 import (
 	"encoding/xml"
+	"strings"
 )
 
 const xmlschema11 = "http://www.w3.org/2001/XMLSchema"
 
 // XSDSchema represents an entire Schema structure.
 type XSDSchema struct {
-	XMLName            xml.Name          `xml:"schema"`
-	Xmlns              map[string]string `xml:"-"`
-	Tns                string            `xml:"xmlns tns,attr"`
-	Xs                 string            `xml:"xmlns xs,attr"`
-	Version            string            `xml:"version,attr"`
-	TargetNamespace    string            `xml:"targetNamespace,attr"`
-	ElementFormDefault string            `xml:"elementFormDefault,attr"`
-	Includes           []*XSDInclude     `xml:"include"`
-	Imports            []*XSDImport      `xml:"import"`
-	Elements           []*XSDElement     `xml:"element"`
-	Attributes         []*XSDAttribute   `xml:"attribute"`
-	ComplexTypes       []*XSDComplexType `xml:"complexType"` // global
-	SimpleType         []*XSDSimpleType  `xml:"simpleType"`
+	XMLName              xml.Name             `xml:"schema"`
+	Xmlns                map[string]string    `xml:"-"`
+	Tns                  string               `xml:"xmlns tns,attr"`
+	Xs                   string               `xml:"xmlns xs,attr"`
+	Version              string               `xml:"version,attr"`
+	TargetNamespace      string               `xml:"targetNamespace,attr"`
+	ElementFormDefault   string               `xml:"elementFormDefault,attr"`
+	AttributeFormDefault string               `xml:"attributeFormDefault,attr"`
+	Includes             []*XSDInclude        `xml:"include"`
+	Imports              []*XSDImport         `xml:"import"`
+	Elements             []*XSDElement        `xml:"element"`
+	Attributes           []*XSDAttribute      `xml:"attribute"`
+	ComplexTypes         []*XSDComplexType    `xml:"complexType"` // global
+	SimpleType           []*XSDSimpleType     `xml:"simpleType"`
+	Groups               []*XSDGroup          `xml:"group"`
+	AttributeGroups      []*XSDAttributeGroup `xml:"attributeGroup"`
+
+	Declaration
 }
 
 // UnmarshalXML implements interface xml.Unmarshaler for XSDSchema.
@@ -32,10 +38,21 @@ func (s *XSDSchema) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	s.Xmlns = make(map[string]string)
 	s.XMLName = start.Name
 	for _, attr := range start.Attr {
+		if attr.Name == positionAttr {
+			if err := s.Pos.UnmarshalXMLAttr(attr); err != nil {
+				return err
+			}
+			continue
+		}
 		if attr.Name.Space == "xmlns" {
 			s.Xmlns[attr.Name.Local] = attr.Value
 			continue
 		}
+		if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			// The default namespace is kept under the empty prefix.
+			s.Xmlns[""] = attr.Value
+			continue
+		}
 
 		switch attr.Name.Local {
 		case "version":
@@ -44,6 +61,8 @@ func (s *XSDSchema) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 			s.TargetNamespace = attr.Value
 		case "elementFormDefault":
 			s.ElementFormDefault = attr.Value
+		case "attributeFormDefault":
+			s.AttributeFormDefault = attr.Value
 		}
 	}
 
@@ -98,6 +117,18 @@ Loop:
 					return err
 				}
 				s.SimpleType = append(s.SimpleType, x)
+			case "group":
+				x := new(XSDGroup)
+				if err := d.DecodeElement(x, &t); err != nil {
+					return err
+				}
+				s.Groups = append(s.Groups, x)
+			case "attributeGroup":
+				x := new(XSDAttributeGroup)
+				if err := d.DecodeElement(x, &t); err != nil {
+					return err
+				}
+				s.AttributeGroups = append(s.AttributeGroups, x)
 			default:
 				d.Skip()
 				continue Loop
@@ -110,9 +141,21 @@ Loop:
 	return nil
 }
 
+// qname resolves a prefixed name used within the schema. Unprefixed names
+// belong to the default namespace, or to the target namespace when the schema
+// declares none, which many schemas rely on.
+func (s *XSDSchema) qname(name string) xml.Name {
+	if _, ok := s.Xmlns[""]; !ok && !strings.Contains(name, ":") {
+		return xml.Name{Space: s.TargetNamespace, Local: name}
+	}
+	return parseQName(name, s.Xmlns)
+}
+
 // XSDInclude represents schema includes.
 type XSDInclude struct {
 	SchemaLocation string `xml:"schemaLocation,attr"`
+
+	Declaration
 }
 
 // XSDImport represents XSD imports within the main schema.
@@ -120,21 +163,34 @@ type XSDImport struct {
 	XMLName        xml.Name `xml:"import"`
 	SchemaLocation string   `xml:"schemaLocation,attr"`
 	Namespace      string   `xml:"namespace,attr"`
+
+	Declaration
 }
 
 // XSDElement represents a Schema element.
 type XSDElement struct {
-	XMLName     xml.Name        `xml:"element"`
-	Name        string          `xml:"name,attr"`
-	Doc         string          `xml:"annotation>documentation"`
-	Nillable    bool            `xml:"nillable,attr"`
-	Type        string          `xml:"type,attr"`
-	Ref         string          `xml:"ref,attr"`
-	MinOccurs   string          `xml:"minOccurs,attr"`
-	MaxOccurs   string          `xml:"maxOccurs,attr"`
-	ComplexType *XSDComplexType `xml:"complexType"` // local
-	SimpleType  *XSDSimpleType  `xml:"simpleType"`
-	Groups      []*XSDGroup     `xml:"group"`
+	XMLName   xml.Name `xml:"element"`
+	Name      string   `xml:"name,attr"`
+	Doc       string   `xml:"annotation>documentation"`
+	Nillable  bool     `xml:"nillable,attr"`
+	Type      string   `xml:"type,attr"`
+	Ref       string   `xml:"ref,attr"`
+	Form      string   `xml:"form,attr"`
+	MinOccurs string   `xml:"minOccurs,attr"`
+	MaxOccurs string   `xml:"maxOccurs,attr"`
+	Abstract  bool     `xml:"abstract,attr"`
+	// SubstitutionGroup is the head of the substitution group of a global
+	// element, the element it may substitute.
+	SubstitutionGroup string          `xml:"substitutionGroup,attr"`
+	ComplexType       *XSDComplexType `xml:"complexType"` // local
+	SimpleType        *XSDSimpleType  `xml:"simpleType"`
+	Groups            []*XSDGroup     `xml:"group"`
+
+	Declaration
+
+	// schema declares the element when it was inlined from the group of
+	// another schema, nil otherwise.
+	schema *XSDSchema
 }
 
 // XSDAny represents a Schema element.
@@ -149,51 +205,130 @@ type XSDAny struct {
 
 // XSDComplexType represents a Schema complex type.
 type XSDComplexType struct {
-	XMLName        xml.Name          `xml:"complexType"`
-	Abstract       bool              `xml:"abstract,attr"`
-	Name           string            `xml:"name,attr"`
-	Mixed          bool              `xml:"mixed,attr"`
-	Sequence       []*XSDElement     `xml:"sequence>element"`
-	Choice         []*XSDElement     `xml:"choice>element"`
-	SequenceChoice []*XSDElement     `xml:"sequence>choice>element"`
-	All            []*XSDElement     `xml:"all>element"`
-	ComplexContent XSDComplexContent `xml:"complexContent"`
-	SimpleContent  XSDSimpleContent  `xml:"simpleContent"`
-	Attributes     []*XSDAttribute   `xml:"attribute"`
-	Any            []*XSDAny         `xml:"sequence>any"`
+	XMLName         xml.Name          `xml:"complexType"`
+	Abstract        bool              `xml:"abstract,attr"`
+	Name            string            `xml:"name,attr"`
+	Mixed           bool              `xml:"mixed,attr"`
+	Sequence        []*XSDElement     `xml:"sequence>element"`
+	Choice          *XSDChoice        `xml:"choice"`
+	SequenceChoices []*XSDChoice      `xml:"sequence>choice"`
+	All             []*XSDElement     `xml:"all>element"`
+	ComplexContent  XSDComplexContent `xml:"complexContent"`
+	SimpleContent   XSDSimpleContent  `xml:"simpleContent"`
+	Attributes      []*XSDAttribute   `xml:"attribute"`
+	Any             []*XSDAny         `xml:"sequence>any"`
+
+	// Group references, inlined by the traverser.
+	Groups          []*XSDGroup          `xml:"group"`
+	SequenceGroups  []*XSDGroup          `xml:"sequence>group"`
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+
+	Declaration
 }
 
 // XSDGroup element is used to define a group of elements to be used in complex type definitions.
 type XSDGroup struct {
-	Name     string       `xml:"name,attr"`
-	Ref      string       `xml:"ref,attr"`
-	Sequence []XSDElement `xml:"sequence>element"`
-	Choice   []XSDElement `xml:"choice>element"`
-	All      []XSDElement `xml:"all>element"`
+	Name            string        `xml:"name,attr"`
+	Ref             string        `xml:"ref,attr"`
+	Sequence        []*XSDElement `xml:"sequence>element"`
+	Choice          *XSDChoice    `xml:"choice"`
+	SequenceChoices []*XSDChoice  `xml:"sequence>choice"`
+	All             []*XSDElement `xml:"all>element"`
+	SequenceGroups  []*XSDGroup   `xml:"sequence>group"`
+
+	Declaration
+}
+
+// XSDChoice represents a choice, one of whose elements occurs per occurrence
+// of the choice.
+type XSDChoice struct {
+	MinOccurs string        `xml:"minOccurs,attr"`
+	MaxOccurs string        `xml:"maxOccurs,attr"`
+	Elements  []*XSDElement `xml:"element"`
+
+	// Group references, inlined by the traverser.
+	Groups []*XSDGroup `xml:"group"`
+}
+
+// choiceElements returns the elements of choices, which may be nil.
+func choiceElements(choices ...*XSDChoice) []*XSDElement {
+	var elms []*XSDElement
+	for _, choice := range choices {
+		if choice != nil {
+			elms = append(elms, choice.Elements...)
+		}
+	}
+	return elms
+}
+
+// XSDAttributeGroup element defines a group of attributes to be used in
+// complex type definitions.
+type XSDAttributeGroup struct {
+	Name            string               `xml:"name,attr"`
+	Ref             string               `xml:"ref,attr"`
+	Attributes      []*XSDAttribute      `xml:"attribute"`
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+
+	Declaration
 }
 
 // XSDComplexContent element defines extensions or restrictions on a complex
 // type that contains mixed content or elements only.
 type XSDComplexContent struct {
-	XMLName   xml.Name     `xml:"complexContent"`
-	Extension XSDExtension `xml:"extension"`
+	XMLName     xml.Name              `xml:"complexContent"`
+	Extension   XSDExtension          `xml:"extension"`
+	Restriction XSDComplexRestriction `xml:"restriction"`
 }
 
 // XSDSimpleContent element contains extensions or restrictions on a text-only
 // complex type or on a simple type as content and contains no elements.
 type XSDSimpleContent struct {
-	XMLName   xml.Name     `xml:"simpleContent"`
-	Extension XSDExtension `xml:"extension"`
+	XMLName     xml.Name                    `xml:"simpleContent"`
+	Extension   XSDExtension                `xml:"extension"`
+	Restriction XSDSimpleContentRestriction `xml:"restriction"`
 }
 
 // XSDExtension element extends an existing simpleType or complexType element.
 type XSDExtension struct {
-	XMLName        xml.Name        `xml:"extension"`
-	Base           string          `xml:"base,attr"`
-	Attributes     []*XSDAttribute `xml:"attribute"`
-	Sequence       []*XSDElement   `xml:"sequence>element"`
-	Choice         []*XSDElement   `xml:"choice>element"`
-	SequenceChoice []*XSDElement   `xml:"sequence>choice>element"`
+	XMLName         xml.Name        `xml:"extension"`
+	Base            string          `xml:"base,attr"`
+	Attributes      []*XSDAttribute `xml:"attribute"`
+	Sequence        []*XSDElement   `xml:"sequence>element"`
+	Choice          *XSDChoice      `xml:"choice"`
+	SequenceChoices []*XSDChoice    `xml:"sequence>choice"`
+
+	// Group references, inlined by the traverser.
+	Groups          []*XSDGroup          `xml:"group"`
+	SequenceGroups  []*XSDGroup          `xml:"sequence>group"`
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+}
+
+// XSDComplexRestriction element restricts the content model of a complex type,
+// restating the elements it keeps. The attributes of the base type are kept
+// unless prohibited. Restrictions of soapenc:Array declare SOAP encoded arrays.
+type XSDComplexRestriction struct {
+	Base            string          `xml:"base,attr"`
+	Attributes      []*XSDAttribute `xml:"attribute"`
+	Sequence        []*XSDElement   `xml:"sequence>element"`
+	Choice          *XSDChoice      `xml:"choice"`
+	SequenceChoices []*XSDChoice    `xml:"sequence>choice"`
+	All             []*XSDElement   `xml:"all>element"`
+
+	// Group references, inlined by the traverser.
+	Groups          []*XSDGroup          `xml:"group"`
+	SequenceGroups  []*XSDGroup          `xml:"sequence>group"`
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+}
+
+// XSDSimpleContentRestriction element restricts the value of a complex type
+// with simple content by facets. The attributes of the base type are kept
+// unless prohibited.
+type XSDSimpleContentRestriction struct {
+	XSDRestriction
+	Attributes []*XSDAttribute `xml:"attribute"`
+
+	// Attribute group references, inlined by the traverser.
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
 }
 
 // XSDAttribute represent an element attribute. Simple elements cannot have
@@ -203,10 +338,18 @@ type XSDAttribute struct {
 	Doc        string         `xml:"annotation>documentation"`
 	Name       string         `xml:"name,attr"`
 	Ref        string         `xml:"ref,attr"`
+	Form       string         `xml:"form,attr"`
 	Type       string         `xml:"type,attr"`
 	Use        string         `xml:"use,attr"`
 	Fixed      string         `xml:"fixed,attr"`
+	ArrayType  string         `xml:"http://schemas.xmlsoap.org/wsdl/ arrayType,attr"`
 	SimpleType *XSDSimpleType `xml:"simpleType"`
+
+	Declaration
+
+	// schema declares the attribute when it was inlined from the attribute
+	// group of another schema, nil otherwise.
+	schema *XSDSchema
 }
 
 // XSDSimpleType element defines a simple type and specifies the constraints
@@ -218,6 +361,8 @@ type XSDSimpleType struct {
 	List        XSDList        `xml:"list"`
 	Union       XSDUnion       `xml:"union"`
 	Final       string         `xml:"final"`
+
+	Declaration
 }
 
 // XSDList represents a element list
@@ -237,7 +382,7 @@ type XSDUnion struct {
 type XSDRestriction struct {
 	Base         string                `xml:"base,attr"`
 	Enumeration  []XSDRestrictionValue `xml:"enumeration"`
-	Pattern      XSDRestrictionValue   `xml:"pattern"`
+	Pattern      []XSDRestrictionValue `xml:"pattern"`
 	MinInclusive XSDRestrictionValue   `xml:"minInclusive"`
 	MaxInclusive XSDRestrictionValue   `xml:"maxInclusive"`
 	WhiteSpace   XSDRestrictionValue   `xml:"whitespace"`